@@ -1,5 +1,7 @@
 package countries
 
+import "time"
+
 type Country string
 
 // Country represents a country code.
@@ -9,6 +11,28 @@ const (
 	Default           Country = "do"
 )
 
+// timezones maps each country to the IANA time zone its dates are expressed in.
+var timezones = map[Country]string{
+	DominicanRepublic: "America/Santo_Domingo",
+	UnitedStates:      "America/New_York",
+}
+
 func (l Country) String() string {
 	return string(l)
 }
+
+// Location returns the time zone of the country, or UTC for unknown countries.
+// It reads the system time zone database; applications running on images without zoneinfo
+// should import time/tzdata in their main package to embed it.
+func (l Country) Location() *time.Location {
+	name, ok := timezones[l]
+	if !ok {
+		return time.UTC
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
@@ -0,0 +1,227 @@
+package databases
+
+import (
+	"time"
+
+	"github.com/educolog9/packages/enums"
+	"github.com/educolog9/packages/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ConvertPaginationToAtlasSearchMetaPipeline builds an aggregation that counts the documents matching the
+// search and filters of a PaginationConfig using Atlas Search. It returns a single document shaped like
+// the $searchMeta output: {"count": {"total": <n>}}.
+// When every filter can be folded into the search, the pipeline is a single $searchMeta stage; otherwise
+// it falls back to $search, a $match of the remaining filters and a $group counting the documents.
+// It returns a nil pipeline when the config does not use Atlas Search.
+func ConvertPaginationToAtlasSearchMetaPipeline(config *types.PaginationConfig) (mongo.Pipeline, error) {
+	if !config.UsesAtlasSearch() {
+		return nil, nil
+	}
+
+	searchConfig, clauses, err := splitAtlasSearchFilters(config)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := buildMongoFilter(searchConfig, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(filter) == 0 {
+		stage := buildAtlasSearchStage("$searchMeta", config, clauses)
+		stage[0].Value = append(stage[0].Value.(bson.D), bson.E{Key: "count", Value: bson.D{{Key: "type", Value: "total"}}})
+		return mongo.Pipeline{stage}, nil
+	}
+
+	return mongo.Pipeline{
+		buildAtlasSearchStage("$search", config, clauses),
+		bson.D{{Key: "$match", Value: filter}},
+		bson.D{{Key: "$group", Value: bson.D{{Key: "_id", Value: nil}, {Key: "total", Value: bson.D{{Key: "$sum", Value: 1}}}}}},
+		bson.D{{Key: "$project", Value: bson.D{{Key: "_id", Value: 0}, {Key: "count", Value: bson.D{{Key: "total", Value: "$total"}}}}}},
+	}, nil
+}
+
+// buildMongoSearchStage returns the $search stage of the config, and the config whose filters
+// are still to be applied in a $match after it.
+// When the config does not use Atlas Search, it returns a nil stage and the config itself.
+func buildMongoSearchStage(config *types.PaginationConfig) (bson.D, *types.PaginationConfig, error) {
+	if !config.UsesAtlasSearch() {
+		return nil, config, nil
+	}
+
+	searchConfig, clauses, err := splitAtlasSearchFilters(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return buildAtlasSearchStage("$search", config, clauses), searchConfig, nil
+}
+
+// buildAtlasSearchStage builds a $search or $searchMeta stage with a compound operator: the text query
+// of the pagination as a must clause, and the filter clauses folded from the pagination filters.
+func buildAtlasSearchStage(stageName string, config *types.PaginationConfig, clauses atlasSearchClauses) bson.D {
+	options := config.GetAtlasSearch()
+
+	text := bson.D{
+		{Key: "query", Value: config.Pagination.GetSearch()},
+		{Key: "path", Value: buildAtlasSearchPath(options.Paths)},
+	}
+	if options.Fuzzy != nil {
+		fuzzy := bson.D{}
+		if options.Fuzzy.MaxEdits > 0 {
+			fuzzy = append(fuzzy, bson.E{Key: "maxEdits", Value: options.Fuzzy.MaxEdits})
+		}
+		if options.Fuzzy.PrefixLength > 0 {
+			fuzzy = append(fuzzy, bson.E{Key: "prefixLength", Value: options.Fuzzy.PrefixLength})
+		}
+		if options.Fuzzy.MaxExpansions > 0 {
+			fuzzy = append(fuzzy, bson.E{Key: "maxExpansions", Value: options.Fuzzy.MaxExpansions})
+		}
+		text = append(text, bson.E{Key: "fuzzy", Value: fuzzy})
+	}
+
+	compound := bson.D{{Key: "must", Value: bson.A{bson.D{{Key: "text", Value: text}}}}}
+	if len(clauses.Filter) > 0 {
+		compound = append(compound, bson.E{Key: "filter", Value: clauses.Filter})
+	}
+	if len(clauses.MustNot) > 0 {
+		compound = append(compound, bson.E{Key: "mustNot", Value: clauses.MustNot})
+	}
+
+	return bson.D{{Key: stageName, Value: bson.D{
+		{Key: "index", Value: options.GetIndex()},
+		{Key: "compound", Value: compound},
+	}}}
+}
+
+// atlasSearchClauses are the compound clauses folded from the pagination filters.
+type atlasSearchClauses struct {
+	Filter  bson.A
+	MustNot bson.A
+}
+
+// splitAtlasSearchFilters folds the top-level filters that Atlas Search can evaluate from its index
+// into compound clauses: equality, inequality and ranges on numbers, dates, booleans and ObjectIDs,
+// in on those same types, and exists. Strings, groups and the other operators are left out because
+// their Atlas Search semantics depend on how the field is analyzed; they are returned in a copy of the
+// config, without the search text, to be applied in a $match after the $search stage.
+func splitAtlasSearchFilters(config *types.PaginationConfig) (*types.PaginationConfig, atlasSearchClauses, error) {
+	var clauses atlasSearchClauses
+	var remaining []types.Filter
+
+	config, err := resolvePaginationDates(config)
+	if err != nil {
+		return nil, clauses, err
+	}
+
+	if _, _, err := resolveTenantScope(config.Tenant, config.Pagination.GetFilters()); err != nil {
+		return nil, clauses, err
+	}
+
+	for _, f := range config.Pagination.GetFilters() {
+		if f.IsGroup() {
+			remaining = append(remaining, f)
+			continue
+		}
+
+		switch f.Operator {
+		case enums.Equal, enums.NotEqual:
+			value, err := f.ResolveValue()
+			if err != nil {
+				return nil, clauses, err
+			}
+			if !isAtlasSearchValue(value) {
+				remaining = append(remaining, f)
+				continue
+			}
+			clause := bson.D{{Key: "equals", Value: bson.D{{Key: "path", Value: f.Field}, {Key: "value", Value: value}}}}
+			if f.Operator == enums.Equal {
+				clauses.Filter = append(clauses.Filter, clause)
+			} else {
+				clauses.MustNot = append(clauses.MustNot, clause)
+			}
+		case enums.GreaterThan, enums.GreaterThanOrEqual, enums.LessThan, enums.LessThanOrEqual:
+			value, err := f.ResolveValue()
+			if err != nil {
+				return nil, clauses, err
+			}
+			if !isAtlasSearchValue(value) {
+				remaining = append(remaining, f)
+				continue
+			}
+			if _, ok := value.(bool); ok {
+				remaining = append(remaining, f)
+				continue
+			}
+			operator := mongoComparisonOperators[f.Operator][1:]
+			clauses.Filter = append(clauses.Filter, bson.D{{Key: "range", Value: bson.D{{Key: "path", Value: f.Field}, {Key: operator, Value: value}}}})
+		case enums.In:
+			values, err := buildMongoListValue(f)
+			if err != nil {
+				return nil, clauses, err
+			}
+			if len(values) == 0 || !allAtlasSearchValues(values) {
+				remaining = append(remaining, f)
+				continue
+			}
+			clauses.Filter = append(clauses.Filter, bson.D{{Key: "in", Value: bson.D{{Key: "path", Value: f.Field}, {Key: "value", Value: values}}}})
+		case enums.Exists:
+			exists, err := types.FilterValueToBool(f.Value)
+			if err != nil {
+				return nil, clauses, types.InvalidFilterValueError(f.Operator, "a boolean")
+			}
+			clause := bson.D{{Key: "exists", Value: bson.D{{Key: "path", Value: f.Field}}}}
+			if exists {
+				clauses.Filter = append(clauses.Filter, clause)
+			} else {
+				clauses.MustNot = append(clauses.MustNot, clause)
+			}
+		default:
+			remaining = append(remaining, f)
+		}
+	}
+
+	pagination := *config.Pagination
+	pagination.Filters = remaining
+	pagination.Search = ""
+
+	searchConfig := *config
+	searchConfig.Pagination = &pagination
+
+	return &searchConfig, clauses, nil
+}
+
+func buildAtlasSearchPath(paths []string) interface{} {
+	switch len(paths) {
+	case 0:
+		return bson.D{{Key: "wildcard", Value: "*"}}
+	case 1:
+		return paths[0]
+	default:
+		return paths
+	}
+}
+
+// isAtlasSearchValue checks if a value has a type the equals, range and in operators of Atlas Search support
+// without depending on how the field is analyzed.
+func isAtlasSearchValue(value interface{}) bool {
+	switch value.(type) {
+	case bool, primitive.ObjectID, primitive.DateTime, time.Time, int, int32, int64, float64:
+		return true
+	default:
+		return false
+	}
+}
+
+func allAtlasSearchValues(values []interface{}) bool {
+	for _, value := range values {
+		if !isAtlasSearchValue(value) {
+			return false
+		}
+	}
+	return true
+}
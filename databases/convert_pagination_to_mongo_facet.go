@@ -0,0 +1,82 @@
+package databases
+
+import (
+	"context"
+
+	"github.com/educolog9/packages/enums"
+	"github.com/educolog9/packages/types"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// facetResult is the document returned by a pipeline built with ConvertPaginationToMongoFacetPipeline.
+type facetResult[T any] struct {
+	Items []T `bson:"items"`
+	Total []struct {
+		Count int64 `bson:"count"`
+	} `bson:"total"`
+	Facets map[string][]types.FacetBucket `bson:",inline"`
+}
+
+// ConvertPaginationToMongoFacetPipeline builds a single aggregation returning a page and the total count.
+// The pipeline starts with a $match of the pagination filters (preceded by the $search stage when the
+// config uses Atlas Search, see ConvertPaginationToMongoPipeline), followed by the stages of the base pipeline
+// (e.g. $lookup or $addFields), and ends with a $facet with two sub-pipelines: "items" with the cursor range,
+// $sort, $skip and $limit of the page, and "total" with the $count of every document matching the filters.
+// The facets requested by the pagination are counted in sub-pipelines of the same $facet, sharing its filters.
+// It is a shortcut for NewPipelineBuilder(config).Inject(enums.AfterMatch, basePipeline...).BuildFacet().
+// Since the page is sorted inside the $facet, the sort cannot use an index; on large collections prefer
+// running the page and the count as separate queries, as Repository.FindPage does.
+// Use DecodeMongoFacetResult to read the result into a PaginatedResponse.
+func ConvertPaginationToMongoFacetPipeline(config *types.PaginationConfig, basePipeline mongo.Pipeline) (mongo.Pipeline, error) {
+	return NewPipelineBuilder(config).Inject(enums.AfterMatch, basePipeline...).BuildFacet()
+}
+
+// DecodeMongoFacetResult reads the result of a pipeline built with ConvertPaginationToMongoFacetPipeline
+// into a PaginatedResponse. When the config uses cursor pagination, the items are put back in the
+// requested order and the next and prev cursors are set, as done by NewCursorPage.
+// The counts of the requested facets are set in the Facets of the response.
+func DecodeMongoFacetResult[T any](ctx context.Context, cursor *mongo.Cursor, config *types.PaginationConfig) (*types.PaginatedResponse[T], error) {
+	defer cursor.Close(ctx)
+
+	var result facetResult[T]
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&result); err != nil {
+			return nil, err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	var total int64
+	if len(result.Total) > 0 {
+		total = result.Total[0].Count
+	}
+
+	return buildMongoPageResponse(result.Items, total, result.Facets, config)
+}
+
+// buildMongoPageResponse builds the PaginatedResponse of a page read from MongoDB. When the config uses cursor
+// pagination, the items are put back in the requested order and the next and prev cursors are set.
+func buildMongoPageResponse[T any](items []T, total int64, facets map[string][]types.FacetBucket, config *types.PaginationConfig) (*types.PaginatedResponse[T], error) {
+	response := types.NewPaginatedResponse(items, total, config.Pagination)
+	if len(facets) > 0 {
+		response.Facets = facets
+	}
+	if !config.WithLimit {
+		response.HasMore = false
+	}
+
+	if config.UsesCursor() {
+		page, err := NewCursorPage(config, response.Items)
+		if err != nil {
+			return nil, err
+		}
+		response.Items = page.Items
+		response.Next = page.Next
+		response.Prev = page.Prev
+		response.HasMore = page.Next != ""
+	}
+
+	return response, nil
+}
@@ -1,13 +1,8 @@
 package databases
 
 import (
-	"fmt"
-	"reflect"
-
-	"github.com/educolog9/packages/enums"
 	"github.com/educolog9/packages/types"
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -25,109 +20,33 @@ func ConvertPaginationToMongoFilter(config *types.PaginationConfig) (bson.M, *op
 	withLimit := config.WithLimit
 	pagination := config.Pagination
 
-	if pagination.GetSort() != "" {
-		sortOrder := 1
-		if pagination.GetOrder() == "desc" {
-			sortOrder = -1
-		}
-		findOptions.SetSort(bson.D{{Key: pagination.GetSort(), Value: sortOrder}})
+	sortKeys := getSortKeys(config)
+	if len(sortKeys) > 0 {
+		findOptions.SetSort(buildMongoSort(sortKeys))
 	}
 
 	if withLimit {
-		findOptions.SetSkip(pagination.GetOffset())
+		if !config.UsesCursor() {
+			findOptions.SetSkip(pagination.GetOffset())
+		}
 		findOptions.SetLimit(pagination.GetLimit())
 	}
 
-	filter := bson.M{}
-	if pagination.GetSearch() != "" {
-		filter = bson.M{"$text": bson.M{"$search": pagination.GetSearch()}}
+	projection, err := buildMongoProjection(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(projection) > 0 {
+		findOptions.SetProjection(projection)
 	}
 
-	for _, f := range pagination.GetFilters() {
-		value := f.Value
-		if reflect.TypeOf(f.Value).Kind() == reflect.String {
-			id, err := primitive.ObjectIDFromHex(f.Value.(string))
-			if err == nil {
-				value = id
-			}
-		}
-
-		switch f.Operator {
-		case enums.Equal:
-			filter[f.Field] = bson.M{"$eq": value}
-		case enums.NotEqual:
-			filter[f.Field] = bson.M{"$ne": value}
-		case enums.GreaterThan:
-			filter[f.Field] = bson.M{"$gt": value}
-		case enums.GreaterThanOrEqual:
-			filter[f.Field] = bson.M{"$gte": value}
-		case enums.LessThan:
-			filter[f.Field] = bson.M{"$lt": value}
-		case enums.LessThanOrEqual:
-			filter[f.Field] = bson.M{"$lte": value}
-		case enums.In:
-			switch v := value.(type) {
-			case []string:
-				if len(v) > 0 {
-					// Attempt to convert the first element to see if it's a valid ObjectID
-					if _, err := primitive.ObjectIDFromHex(v[0]); err == nil {
-						// If the first element is a valid ObjectID, attempt to convert the entire slice
-						var objectIDs []primitive.ObjectID
-						for _, str := range v {
-							if objID, err := primitive.ObjectIDFromHex(str); err == nil {
-								objectIDs = append(objectIDs, objID)
-							}
-						}
-						if len(objectIDs) > 0 {
-							filter[f.Field] = bson.M{"$in": objectIDs}
-						}
-						break // Break after handling conversion to ObjectIDs
-					}
-					filter[f.Field] = bson.M{"$in": v}
-				}
-
-				filter[f.Field] = bson.M{"$in": v}
-			case []interface{}:
-				filter[f.Field] = bson.M{"$in": v}
-			case string:
-				filter[f.Field] = bson.M{"$in": []string{v}}
-			default:
-				return nil, nil, fmt.Errorf("invalid format for 'in' operator")
-			}
-		case enums.NotIn:
-			switch v := value.(type) {
-			case []string:
-				if len(v) > 0 {
-					if _, err := primitive.ObjectIDFromHex(v[0]); err == nil {
-						var objectIDs []primitive.ObjectID
-						for _, str := range v {
-							if objID, err := primitive.ObjectIDFromHex(str); err == nil {
-								objectIDs = append(objectIDs, objID)
-							}
-						}
-						if len(objectIDs) > 0 {
-							filter[f.Field] = bson.M{"$nin": objectIDs}
-						}
-						break
-					}
-					filter[f.Field] = bson.M{"$nin": v}
-				}
+	if collation := BuildMongoCollation(config); collation != nil {
+		findOptions.SetCollation(collation)
+	}
 
-				filter[f.Field] = bson.M{"$nin": v}
-			case []interface{}:
-				filter[f.Field] = bson.M{"$nin": v}
-			case string:
-				filter[f.Field] = bson.M{"$nin": []string{v}}
-			default:
-				return nil, nil, fmt.Errorf("invalid format for 'not in' operator")
-			}
-		case enums.Like:
-			filter[f.Field] = bson.M{"$regex": value}
-		case enums.NotLike:
-			filter[f.Field] = bson.M{"$not": bson.M{"$regex": value}}
-		default:
-			return nil, nil, fmt.Errorf("unsupported operator %s", f.Operator)
-		}
+	filter, err := buildMongoPageFilter(config, sortKeys, true)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	return filter, findOptions, nil
@@ -135,151 +54,56 @@ func ConvertPaginationToMongoFilter(config *types.PaginationConfig) (bson.M, *op
 
 // ConvertPaginationToMongoPipeline converts a PaginationConfig into a MongoDB filter and pipeline.
 // It takes a PaginationConfig as input and returns a bson.M filter, []bson.M pipeline, and an error.
+// Cursor pagination and projection are handled the same way as in ConvertPaginationToMongoFilter,
+// the projection as a $project stage after $limit.
+// When WithAtlasSearch is set and the pagination has a search text, the pipeline starts with a $search stage
+// built from the AtlasSearch options, with the filters Atlas Search can evaluate folded into its compound
+// clauses; the returned filter only holds the remaining ones and must be applied in a $match right after
+// the $search stage, since Atlas requires $search to be the first stage of the pipeline.
+// Likewise, a near filter is translated into a $geoNear first stage whose query holds every other filter,
+// in which case the returned filter only holds the cursor range.
+// Use NewPipelineBuilder to get the full pipeline with the $match already in place, and run it with
+// NewMongoAggregateOptions so it uses the collation of the language of the config.
 func ConvertPaginationToMongoPipeline(config *types.PaginationConfig) (bson.M, mongo.Pipeline, error) {
 	var pipeline []bson.D
 
 	withLimit := config.WithLimit
 	pagination := config.Pagination
 
-	if pagination.GetSort() != "" {
-		sortOrder := 1
-		if pagination.GetOrder() == "desc" {
-			sortOrder = -1
-		}
-		pipeline = append(pipeline, bson.D{{Key: "$sort", Value: bson.D{{Key: pagination.GetSort(), Value: sortOrder}}}})
+	leadingStage, filter, err := buildMongoLeadingStage(config)
+	if err != nil {
+		return nil, nil, err
 	}
-
-	if withLimit {
-		pipeline = append(pipeline, bson.D{{Key: "$skip", Value: pagination.GetOffset()}})
-		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: pagination.GetLimit()}})
+	if leadingStage != nil {
+		pipeline = append(pipeline, leadingStage)
 	}
 
-	filter := bson.M{}
-	if pagination.GetSearch() != "" && !config.WithAtlasSearch {
-		filter = bson.M{"$text": bson.M{"$search": pagination.GetSearch()}}
+	sortKeys := getSortKeys(config)
+	if len(sortKeys) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$sort", Value: buildMongoSort(sortKeys)}})
 	}
 
-	for _, f := range pagination.GetFilters() {
-		value := f.Value
-		if reflect.TypeOf(f.Value).Kind() == reflect.String {
-			id, err := primitive.ObjectIDFromHex(f.Value.(string))
-			if err == nil {
-				value = id
-			}
+	if withLimit {
+		if !config.UsesCursor() {
+			pipeline = append(pipeline, bson.D{{Key: "$skip", Value: pagination.GetOffset()}})
 		}
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: pagination.GetLimit()}})
+	}
 
-		switch f.Operator {
-		case enums.Equal:
-			filter[f.Field] = bson.M{"$eq": value}
-		case enums.NotEqual:
-			filter[f.Field] = bson.M{"$ne": value}
-		case enums.GreaterThan:
-			filter[f.Field] = bson.M{"$gt": value}
-		case enums.GreaterThanOrEqual:
-			filter[f.Field] = bson.M{"$gte": value}
-		case enums.LessThan:
-			filter[f.Field] = bson.M{"$lt": value}
-		case enums.LessThanOrEqual:
-			filter[f.Field] = bson.M{"$lte": value}
-		case enums.In:
-			switch v := value.(type) {
-			case []string:
-				if len(v) > 0 {
-					// Attempt to convert the first element to see if it's a valid ObjectID
-					if _, err := primitive.ObjectIDFromHex(v[0]); err == nil {
-						// If the first element is a valid ObjectID, attempt to convert the entire slice
-						var objectIDs []primitive.ObjectID
-						for _, str := range v {
-							if objID, err := primitive.ObjectIDFromHex(str); err == nil {
-								objectIDs = append(objectIDs, objID)
-							}
-						}
-						if len(objectIDs) > 0 {
-							filter[f.Field] = bson.M{"$in": objectIDs}
-						}
-						break // Break after handling conversion to ObjectIDs
-					}
-					filter[f.Field] = bson.M{"$in": v}
-				}
-
-				filter[f.Field] = bson.M{"$in": v}
-			case []interface{}:
-				if len(v) > 0 {
-					if str, ok := v[0].(string); ok {
-						if _, err := primitive.ObjectIDFromHex(str); err == nil {
-							var objectIDs []primitive.ObjectID
-							for _, item := range v {
-								if str, ok := item.(string); ok {
-									if objID, err := primitive.ObjectIDFromHex(str); err == nil {
-										objectIDs = append(objectIDs, objID)
-									}
-								}
-							}
-							if len(objectIDs) > 0 {
-								filter[f.Field] = bson.M{"$in": objectIDs}
-							}
-							break
-						}
-					}
-				}
-				filter[f.Field] = bson.M{"$in": v}
-			case string:
-				filter[f.Field] = bson.M{"$in": []string{v}}
-			default:
-				return nil, nil, fmt.Errorf("invalid format for 'in' operator")
-			}
-		case enums.NotIn:
-			switch v := value.(type) {
-			case []string:
-				if len(v) > 0 {
-					if _, err := primitive.ObjectIDFromHex(v[0]); err == nil {
-						var objectIDs []primitive.ObjectID
-						for _, str := range v {
-							if objID, err := primitive.ObjectIDFromHex(str); err == nil {
-								objectIDs = append(objectIDs, objID)
-							}
-						}
-						if len(objectIDs) > 0 {
-							filter[f.Field] = bson.M{"$nin": objectIDs}
-						}
-						break
-					}
-					filter[f.Field] = bson.M{"$nin": v}
-				}
-				filter[f.Field] = bson.M{"$nin": v}
+	projection, err := buildMongoProjection(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(projection) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$project", Value: projection}})
+	}
 
-			case []interface{}:
-				if len(v) > 0 {
-					if str, ok := v[0].(string); ok {
-						if _, err := primitive.ObjectIDFromHex(str); err == nil {
-							var objectIDs []primitive.ObjectID
-							for _, item := range v {
-								if str, ok := item.(string); ok {
-									if objID, err := primitive.ObjectIDFromHex(str); err == nil {
-										objectIDs = append(objectIDs, objID)
-									}
-								}
-							}
-							if len(objectIDs) > 0 {
-								filter[f.Field] = bson.M{"$nin": objectIDs}
-							}
-							break
-						}
-					}
-				}
-				filter[f.Field] = bson.M{"$nin": v}
-			case string:
-				filter[f.Field] = bson.M{"$nin": []string{v}}
-			default:
-				return nil, nil, fmt.Errorf("invalid format for 'not in' operator")
-			}
-		case enums.Like:
-			filter[f.Field] = bson.M{"$regex": value}
-		case enums.NotLike:
-			filter[f.Field] = bson.M{"$not": bson.M{"$regex": value}}
-		default:
-			return nil, nil, fmt.Errorf("unsupported operator %s", f.Operator)
-		}
+	cursorFilter, err := buildCursorFilter(config, sortKeys)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cursorFilter != nil {
+		appendMongoAnd(filter, cursorFilter)
 	}
 
 	return filter, mongo.Pipeline(pipeline), nil
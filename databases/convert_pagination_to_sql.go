@@ -0,0 +1,317 @@
+package databases
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/educolog9/packages/types"
+)
+
+// SQLClause holds the parameterized clauses of a paginated SQL query, using PostgreSQL placeholders ($1, $2, ...).
+// Args are ordered as the placeholders appear: first the filter arguments, then the cursor range
+// arguments and last the limit and offset, so CountArgs can be used with the Where clause alone.
+type SQLClause struct {
+	// Columns is the select list: the quoted Pagination.Fields, or "*" when every column is requested.
+	Columns string
+	// Where is the condition of the filters and search, without the WHERE keyword. Empty when there is none.
+	Where string
+	// Cursor is the keyset range condition of the next or prev cursor. Empty when there is none.
+	Cursor string
+	// OrderBy is the sort specification, without the ORDER BY keywords. Empty when the query is not sorted.
+	OrderBy string
+	// Limit is the LIMIT and OFFSET clause. Empty when the config has no limit.
+	Limit string
+	// Args are the arguments of every placeholder.
+	Args []interface{}
+	// CountArgs are the arguments of the placeholders of Where.
+	CountArgs []interface{}
+}
+
+// ConvertPaginationToSQL converts a PaginationConfig into the clauses of a parameterized PostgreSQL query,
+// with the same filter, sort and cursor semantics as ConvertPaginationToMongoFilter.
+// Fields are quoted as identifiers and every value is passed as an argument, so no client input is
+// embedded in the query. The _id tiebreaker of the sort is replaced by the key column of the options,
+// and the search text is matched with ILIKE against the search columns of the options.
+// When the config has a Tenant, the rows are restricted to its organization.
+// Text operators are translated into LIKE and ILIKE with the value escaped, or into the ~ and ~*
+// regex operators in raw mode; elem match and the geo operators are not supported.
+// Use NewSQLCursorPage to build the cursors of the fetched rows.
+func ConvertPaginationToSQL(config *types.PaginationConfig, options *types.SQLOptions) (*SQLClause, error) {
+	if options == nil {
+		options = &types.SQLOptions{}
+	}
+
+	config, err := resolvePaginationDates(config)
+	if err != nil {
+		return nil, err
+	}
+
+	pagination := config.Pagination
+	builder := &sqlBuilder{}
+	clause := &SQLClause{Columns: "*"}
+
+	field, organization, err := resolveTenantScope(config.Tenant, pagination.GetFilters())
+	if err != nil {
+		return nil, err
+	}
+
+	where, err := builder.buildSQLFilterTree(pagination.GetFilters())
+	if err != nil {
+		return nil, err
+	}
+
+	if field != "" {
+		column, err := quoteSQLIdentifier(field)
+		if err != nil {
+			return nil, err
+		}
+		if where != "" {
+			where += " AND "
+		}
+		where += fmt.Sprintf("%s = %s", column, builder.param(organization))
+	}
+
+	if pagination.GetSearch() != "" {
+		search, err := builder.buildSQLSearchCondition(pagination.GetSearch(), options)
+		if err != nil {
+			return nil, err
+		}
+		if where != "" {
+			where += " AND "
+		}
+		where += search
+	}
+
+	clause.Where = where
+	clause.CountArgs = append([]interface{}{}, builder.args...)
+
+	sortKeys := getSQLSortKeys(config, options)
+
+	clause.Cursor, err = builder.buildSQLCursorCondition(config, sortKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	var orderBy []string
+	for _, key := range sortKeys {
+		column, err := quoteSQLIdentifier(key.Field)
+		if err != nil {
+			return nil, err
+		}
+		// NULLs sort before every other value, as in MongoDB.
+		direction := "ASC NULLS FIRST"
+		if key.Order < 0 {
+			direction = "DESC NULLS LAST"
+		}
+		orderBy = append(orderBy, column+" "+direction)
+	}
+	clause.OrderBy = strings.Join(orderBy, ", ")
+
+	if config.WithLimit {
+		clause.Limit = "LIMIT " + builder.param(pagination.GetLimit())
+		if !config.UsesCursor() {
+			clause.Limit += " OFFSET " + builder.param(pagination.GetOffset())
+		}
+	}
+
+	clause.Columns, err = buildSQLColumns(pagination, sortKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	clause.Args = builder.args
+
+	return clause, nil
+}
+
+// Select returns the query selecting the page from the given table, e.g.
+// SELECT * FROM "users" WHERE "age" >= $1 ORDER BY "id" ASC NULLS FIRST LIMIT $2 OFFSET $3, to be run with Args.
+func (c *SQLClause) Select(table string) (string, error) {
+	from, err := quoteSQLIdentifier(table)
+	if err != nil {
+		return "", err
+	}
+
+	query := "SELECT " + c.Columns + " FROM " + from
+
+	var conditions []string
+	if c.Where != "" {
+		conditions = append(conditions, c.Where)
+	}
+	if c.Cursor != "" {
+		conditions = append(conditions, c.Cursor)
+	}
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	if c.OrderBy != "" {
+		query += " ORDER BY " + c.OrderBy
+	}
+	if c.Limit != "" {
+		query += " " + c.Limit
+	}
+
+	return query, nil
+}
+
+// Count returns the query counting every row of the given table matching the filters, without the
+// cursor range, to be run with CountArgs.
+func (c *SQLClause) Count(table string) (string, error) {
+	from, err := quoteSQLIdentifier(table)
+	if err != nil {
+		return "", err
+	}
+
+	query := "SELECT COUNT(*) FROM " + from
+	if c.Where != "" {
+		query += " WHERE " + c.Where
+	}
+
+	return query, nil
+}
+
+// NewSQLCursorPage builds the page returned to the client from the rows fetched with the clauses of
+// ConvertPaginationToSQL, as NewCursorPage does for MongoDB documents.
+// The value function returns the value of a sort column, or of the key column, in a row.
+func NewSQLCursorPage[T any](config *types.PaginationConfig, options *types.SQLOptions, items []T, value func(item T, column string) interface{}) (*CursorPage[T], error) {
+	if options == nil {
+		options = &types.SQLOptions{}
+	}
+
+	return newCursorPage(config, items, getSQLSortKeys(config, options), func(item T, keys []sortKey) (string, error) {
+		cursor := Cursor{}
+		for _, key := range keys {
+			cursor.Fields = append(cursor.Fields, key.Field)
+			cursor.Values = append(cursor.Values, value(item, key.Field))
+		}
+		return cursor.Encode()
+	})
+}
+
+// getSQLSortKeys returns the sort keys of getSortKeys with the _id tiebreaker replaced by the key column.
+func getSQLSortKeys(config *types.PaginationConfig, options *types.SQLOptions) []sortKey {
+	keys := getSortKeys(config)
+
+	var sqlKeys []sortKey
+	for _, key := range keys {
+		if key.Field == "_id" {
+			key.Field = options.GetKeyColumn()
+		}
+		duplicated := false
+		for _, existing := range sqlKeys {
+			duplicated = duplicated || existing.Field == key.Field
+		}
+		if !duplicated {
+			sqlKeys = append(sqlKeys, key)
+		}
+	}
+
+	return sqlKeys
+}
+
+// buildSQLSearchCondition matches the search text as a literal substring of any of the search columns.
+func (b *sqlBuilder) buildSQLSearchCondition(search string, options *types.SQLOptions) (string, error) {
+	if len(options.SearchColumns) == 0 {
+		return "", fmt.Errorf("search requires at least one search column")
+	}
+
+	pattern := b.param("%" + escapeSQLLike(search) + "%")
+
+	var conditions []string
+	for _, name := range options.SearchColumns {
+		column, err := quoteSQLIdentifier(name)
+		if err != nil {
+			return "", err
+		}
+		conditions = append(conditions, fmt.Sprintf("%s ILIKE %s ESCAPE '\\'", column, pattern))
+	}
+
+	return "(" + strings.Join(conditions, " OR ") + ")", nil
+}
+
+// buildSQLCursorCondition translates the next or prev cursor into a range condition, the same way
+// buildCursorFilter does for MongoDB, with NULLs ordered before every other value as in the ORDER BY
+// clause. It returns an empty condition when there is no cursor.
+func (b *sqlBuilder) buildSQLCursorCondition(config *types.PaginationConfig, keys []sortKey) (string, error) {
+	cursor, err := decodePaginationCursor(config.Pagination, keys)
+	if err != nil || cursor == nil {
+		return "", err
+	}
+
+	// (a > x) OR (a = x AND b > y) OR (a = x AND b = y AND id > z)
+	var conditions []string
+	for i, key := range keys {
+		var and []string
+		for j := 0; j < i; j++ {
+			column, err := quoteSQLIdentifier(keys[j].Field)
+			if err != nil {
+				return "", err
+			}
+			if cursor.Values[j] == nil {
+				and = append(and, column+" IS NULL")
+			} else {
+				and = append(and, fmt.Sprintf("%s = %s", column, b.param(cursor.Values[j])))
+			}
+		}
+
+		column, err := quoteSQLIdentifier(key.Field)
+		if err != nil {
+			return "", err
+		}
+
+		value := cursor.Values[i]
+		switch {
+		case value == nil && key.Order < 0:
+			continue
+		case value == nil:
+			and = append(and, column+" IS NOT NULL")
+		case key.Order < 0:
+			and = append(and, fmt.Sprintf("(%s < %s OR %s IS NULL)", column, b.param(value), column))
+		default:
+			and = append(and, fmt.Sprintf("%s > %s", column, b.param(value)))
+		}
+
+		conditions = append(conditions, "("+strings.Join(and, " AND ")+")")
+	}
+
+	if len(conditions) == 0 {
+		return "", fmt.Errorf("invalid cursor data")
+	}
+
+	return "(" + strings.Join(conditions, " OR ") + ")", nil
+}
+
+// buildSQLColumns returns the select list of the fields requested by the pagination, including the sort
+// columns so NewSQLCursorPage can read them. Excluding fields is not supported in SQL.
+func buildSQLColumns(pagination *types.Pagination, keys []sortKey) (string, error) {
+	if len(pagination.GetExcludeFields()) > 0 {
+		return "", fmt.Errorf("excludeFields is not supported by the SQL converter")
+	}
+	if len(pagination.GetFields()) == 0 {
+		return "*", nil
+	}
+
+	var columns []string
+	seen := map[string]bool{}
+
+	names := append([]string{}, pagination.GetFields()...)
+	for _, key := range keys {
+		names = append(names, key.Field)
+	}
+
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		column, err := quoteSQLIdentifier(name)
+		if err != nil {
+			return "", err
+		}
+		columns = append(columns, column)
+	}
+
+	return strings.Join(columns, ", "), nil
+}
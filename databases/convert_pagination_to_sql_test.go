@@ -0,0 +1,191 @@
+package databases
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/educolog9/packages/enums"
+	"github.com/educolog9/packages/types"
+)
+
+func TestConvertPaginationToSQL(t *testing.T) {
+	byAge := []types.SortField{{Field: "age", Order: enums.Asc}}
+	byAgeDesc := []types.SortField{{Field: "age", Order: enums.Desc}}
+	fields := []string{"age", "id"}
+
+	tests := []struct {
+		name       string
+		pagination *types.Pagination
+		options    *types.SQLOptions
+		wantQuery  string
+		wantArgs   []interface{}
+		wantErr    bool
+	}{
+		{
+			name:       "no filters",
+			pagination: &types.Pagination{Limit: 10},
+			wantQuery:  `SELECT * FROM "users" LIMIT $1 OFFSET $2`,
+			wantArgs:   []interface{}{int64(10), int64(0)},
+		},
+		{
+			name: "conditions on the same field",
+			pagination: &types.Pagination{Limit: 10, Offset: 20, Filters: []types.Filter{
+				{Field: "age", Operator: enums.GreaterThanOrEqual, Value: 18},
+				{Field: "age", Operator: enums.LessThanOrEqual, Value: 30},
+			}},
+			wantQuery: `SELECT * FROM "users" WHERE "age" >= $1 AND "age" <= $2 LIMIT $3 OFFSET $4`,
+			wantArgs:  []interface{}{18, 30, int64(10), int64(20)},
+		},
+		{
+			name: "and, or and not groups",
+			pagination: &types.Pagination{Limit: 10, Filters: []types.Filter{
+				{Group: enums.Or, Filters: []types.Filter{
+					{Field: "name", Operator: enums.Equal, Value: "a"},
+					{Group: enums.And, Filters: []types.Filter{
+						{Field: "age", Operator: enums.Between, Value: []interface{}{18, 30}},
+						{Field: "name", Operator: enums.NotEqual, Value: "b"},
+					}},
+				}},
+				{Group: enums.Not, Filters: []types.Filter{
+					{Field: "status", Operator: enums.In, Value: []interface{}{"closed", "archived"}},
+				}},
+			}},
+			wantQuery: `SELECT * FROM "users" WHERE ("name" = $1 OR ("age" BETWEEN $2 AND $3 AND ("name" <> $4 OR "name" IS NULL))) ` +
+				`AND NOT COALESCE(("status" IN ($5, $6)), false) LIMIT $7 OFFSET $8`,
+			wantArgs: []interface{}{"a", 18, 30, "b", "closed", "archived", int64(10), int64(0)},
+		},
+		{
+			name: "null comparisons and text operators",
+			pagination: &types.Pagination{Limit: 10, Filters: []types.Filter{
+				{Field: "deleted_at", Operator: enums.Equal, Value: nil},
+				{Field: "name", Operator: enums.Like, Value: "50%_off"},
+				{Field: "code", Operator: enums.StartsWith, Value: "ab"},
+			}},
+			wantQuery: `SELECT * FROM "users" WHERE "deleted_at" IS NULL AND "name" LIKE $1 ESCAPE '\' AND "code" LIKE $2 ESCAPE '\' ` +
+				`LIMIT $3 OFFSET $4`,
+			wantArgs: []interface{}{`%50\%\_off%`, "ab%", int64(10), int64(0)},
+		},
+		{
+			name:       "search, fields and sort",
+			pagination: &types.Pagination{Limit: 10, Search: "ann", Fields: []string{"name"}, Sorts: byAgeDesc},
+			options:    &types.SQLOptions{KeyColumn: "uid", SearchColumns: []string{"name", "email"}},
+			wantQuery: `SELECT "name", "age", "uid" FROM "users" WHERE ("name" ILIKE $1 ESCAPE '\' OR "email" ILIKE $1 ESCAPE '\') ` +
+				`ORDER BY "age" DESC NULLS LAST, "uid" ASC NULLS FIRST LIMIT $2 OFFSET $3`,
+			wantArgs: []interface{}{"%ann%", int64(10), int64(0)},
+		},
+		{
+			name: "next cursor ascending",
+			pagination: &types.Pagination{Limit: 10, Sorts: byAge, Next: encodeTestCursor(t, fields, int32(30), "a"), Filters: []types.Filter{
+				{Field: "active", Operator: enums.Equal, Value: true},
+			}},
+			wantQuery: `SELECT * FROM "users" WHERE "active" = $1 AND (("age" > $2) OR ("age" = $3 AND "id" > $4)) ` +
+				`ORDER BY "age" ASC NULLS FIRST, "id" ASC NULLS FIRST LIMIT $5`,
+			wantArgs: []interface{}{true, int32(30), int32(30), "a", int64(10)},
+		},
+		{
+			name:       "next cursor descending keeps nulls after the boundary",
+			pagination: &types.Pagination{Limit: 10, Sorts: byAgeDesc, Next: encodeTestCursor(t, fields, int32(30), "a")},
+			wantQuery: `SELECT * FROM "users" WHERE ((("age" < $1 OR "age" IS NULL)) OR ("age" = $2 AND "id" > $3)) ` +
+				`ORDER BY "age" DESC NULLS LAST, "id" ASC NULLS FIRST LIMIT $4`,
+			wantArgs: []interface{}{int32(30), int32(30), "a", int64(10)},
+		},
+		{
+			name:       "null boundary ascending",
+			pagination: &types.Pagination{Limit: 10, Sorts: byAge, Next: encodeTestCursor(t, fields, nil, "a")},
+			wantQuery: `SELECT * FROM "users" WHERE (("age" IS NOT NULL) OR ("age" IS NULL AND "id" > $1)) ` +
+				`ORDER BY "age" ASC NULLS FIRST, "id" ASC NULLS FIRST LIMIT $2`,
+			wantArgs: []interface{}{"a", int64(10)},
+		},
+		{
+			name:       "null boundary descending",
+			pagination: &types.Pagination{Limit: 10, Sorts: byAgeDesc, Next: encodeTestCursor(t, fields, nil, "a")},
+			wantQuery: `SELECT * FROM "users" WHERE (("age" IS NULL AND "id" > $1)) ` +
+				`ORDER BY "age" DESC NULLS LAST, "id" ASC NULLS FIRST LIMIT $2`,
+			wantArgs: []interface{}{"a", int64(10)},
+		},
+		{
+			name:       "prev cursor reverses the orders",
+			pagination: &types.Pagination{Limit: 10, Sorts: byAge, Prev: encodeTestCursor(t, fields, int32(30), "a")},
+			wantQuery: `SELECT * FROM "users" WHERE ((("age" < $1 OR "age" IS NULL)) OR ("age" = $2 AND ("id" < $3 OR "id" IS NULL))) ` +
+				`ORDER BY "age" DESC NULLS LAST, "id" DESC NULLS LAST LIMIT $4`,
+			wantArgs: []interface{}{int32(30), int32(30), "a", int64(10)},
+		},
+		{
+			name:       "cursor of another sort",
+			pagination: &types.Pagination{Limit: 10, Sorts: byAge, Next: encodeTestCursor(t, []string{"name", "id"}, "x", "a")},
+			wantErr:    true,
+		},
+		{
+			name:       "search without search columns",
+			pagination: &types.Pagination{Limit: 10, Search: "ann"},
+			wantErr:    true,
+		},
+		{
+			name:       "excluded fields",
+			pagination: &types.Pagination{Limit: 10, ExcludeFields: []string{"password"}},
+			wantErr:    true,
+		},
+		{
+			name: "elemMatch",
+			pagination: &types.Pagination{Limit: 10, Filters: []types.Filter{
+				{Field: "items", Operator: enums.ElemMatch, Value: []types.Filter{{Field: "sku", Operator: enums.Equal, Value: "x"}}},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "empty group",
+			pagination: &types.Pagination{Limit: 10, Filters: []types.Filter{
+				{Group: enums.Not},
+			}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clause, err := ConvertPaginationToSQL(types.NewPaginationConfig(tt.pagination), tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConvertPaginationToSQL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			query, err := clause.Select("users")
+			if err != nil {
+				t.Fatalf("Select() error = %v", err)
+			}
+			if query != tt.wantQuery {
+				t.Errorf("Select() = %s, want %s", query, tt.wantQuery)
+			}
+			if !reflect.DeepEqual(clause.Args, tt.wantArgs) {
+				t.Errorf("Args = %#v, want %#v", clause.Args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestSQLClauseCount(t *testing.T) {
+	pagination := &types.Pagination{
+		Limit:   10,
+		Sorts:   []types.SortField{{Field: "age", Order: enums.Asc}},
+		Next:    encodeTestCursor(t, []string{"age", "id"}, int32(30), "a"),
+		Filters: []types.Filter{{Field: "active", Operator: enums.Equal, Value: true}},
+	}
+
+	clause, err := ConvertPaginationToSQL(types.NewPaginationConfig(pagination), nil)
+	if err != nil {
+		t.Fatalf("ConvertPaginationToSQL() error = %v", err)
+	}
+
+	query, err := clause.Count("users")
+	if err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+	if want := `SELECT COUNT(*) FROM "users" WHERE "active" = $1`; query != want {
+		t.Errorf("Count() = %s, want %s", query, want)
+	}
+	if want := []interface{}{true}; !reflect.DeepEqual(clause.CountArgs, want) {
+		t.Errorf("CountArgs = %#v, want %#v", clause.CountArgs, want)
+	}
+}
@@ -0,0 +1,235 @@
+package databases
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/educolog9/packages/types"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Cursor identifies the boundary document of a page for keyset pagination.
+// It holds the sort fields used by the query and the values those fields had in the boundary document,
+// always ending with the _id tiebreaker.
+type Cursor struct {
+	Fields []string      `bson:"f"`
+	Values []interface{} `bson:"v"`
+}
+
+// CursorPage is a page of results together with the cursors to request its neighbour pages.
+// @name CursorPage
+type CursorPage[T any] struct {
+	Items []T    `json:"items"`
+	Next  string `json:"next,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+}
+
+// Encode serializes the cursor into an opaque base64url string.
+// The values are stored as canonical extended JSON so ObjectIDs, dates and numeric types survive the round trip.
+func (c *Cursor) Encode() (string, error) {
+	data, err := bson.MarshalExtJSON(c, true, false)
+	if err != nil {
+		return "", fmt.Errorf("invalid cursor data: %w", err)
+	}
+
+	return base64.URLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor parses a cursor previously produced by Cursor.Encode.
+func DecodeCursor(encoded string) (*Cursor, error) {
+	data, err := base64.URLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor format: %w", err)
+	}
+
+	var cursor Cursor
+	if err := bson.UnmarshalExtJSON(data, true, &cursor); err != nil {
+		return nil, fmt.Errorf("invalid cursor data: %w", err)
+	}
+
+	if len(cursor.Fields) == 0 || len(cursor.Fields) != len(cursor.Values) {
+		return nil, fmt.Errorf("invalid cursor data")
+	}
+
+	return &cursor, nil
+}
+
+// NewCursorPage builds the page returned to the client from the documents fetched with a config
+// produced by ConvertPaginationToMongoFilter or ConvertPaginationToMongoPipeline.
+// When the request used a prev cursor the documents come back in reverse order, so they are
+// reversed again to match the requested sort. The next cursor is only set when the page is full,
+// and the prev cursor is only set when there may be documents before the first one of the page.
+// If the config does not use cursor pagination, the page is returned without cursors.
+// It returns an error when a sort field is missing from a boundary document; see newCursorFromDocument.
+func NewCursorPage[T any](config *types.PaginationConfig, items []T) (*CursorPage[T], error) {
+	return newCursorPage(config, items, getSortKeys(config), func(item T, keys []sortKey) (string, error) {
+		return newCursorFromDocument(item, keys)
+	})
+}
+
+// newCursorPage builds a cursor page from the fetched items, encoding the boundary items with encode.
+func newCursorPage[T any](config *types.PaginationConfig, items []T, keys []sortKey, encode func(item T, keys []sortKey) (string, error)) (*CursorPage[T], error) {
+	page := &CursorPage[T]{Items: items}
+
+	if !config.UsesCursor() || len(items) == 0 {
+		return page, nil
+	}
+
+	pagination := config.Pagination
+	backward := isBackward(pagination)
+
+	if backward {
+		reversed := make([]T, len(items))
+		for i, item := range items {
+			reversed[len(items)-1-i] = item
+		}
+		page.Items = reversed
+	}
+
+	full := config.WithLimit && int64(len(items)) >= pagination.GetLimit()
+
+	first, err := encode(page.Items[0], keys)
+	if err != nil {
+		return nil, err
+	}
+
+	last, err := encode(page.Items[len(page.Items)-1], keys)
+	if err != nil {
+		return nil, err
+	}
+
+	if backward {
+		if full {
+			page.Prev = first
+		}
+		page.Next = last
+	} else {
+		if full {
+			page.Next = last
+		}
+		if pagination.GetNext() != "" {
+			page.Prev = first
+		}
+	}
+
+	return page, nil
+}
+
+// buildCursorFilter translates the next or prev cursor of the pagination into a range filter
+// that selects the documents after the boundary document in the given sort order.
+// The keys must already be reversed for prev cursors, as returned by getSortKeys.
+// Null values, which MongoDB sorts before every other value together with missing fields, are compared
+// in that order: nothing comes before them in descending order, and every non-null value comes after them
+// in ascending order.
+// It returns a nil filter when the pagination carries no cursor.
+func buildCursorFilter(config *types.PaginationConfig, keys []sortKey) (bson.M, error) {
+	pagination := config.Pagination
+
+	cursor, err := decodePaginationCursor(pagination, keys)
+	if err != nil || cursor == nil {
+		return nil, err
+	}
+
+	// (a > x) OR (a = x AND b > y) OR (a = x AND b = y AND _id > z)
+	var conditions bson.A
+	for i, key := range keys {
+		condition := bson.M{}
+		for j := 0; j < i; j++ {
+			if cursor.Values[j] == nil {
+				condition[keys[j].Field] = nil
+			} else {
+				condition[keys[j].Field] = bson.M{"$eq": cursor.Values[j]}
+			}
+		}
+
+		value := cursor.Values[i]
+		switch {
+		case value == nil && key.Order < 0:
+			continue
+		case value == nil:
+			condition[key.Field] = bson.M{"$ne": nil}
+		case key.Order < 0:
+			condition["$or"] = bson.A{
+				bson.M{key.Field: bson.M{"$lt": value}},
+				bson.M{key.Field: nil},
+			}
+		default:
+			condition[key.Field] = bson.M{"$gt": value}
+		}
+
+		conditions = append(conditions, condition)
+	}
+
+	if len(conditions) == 0 {
+		return nil, fmt.Errorf("invalid cursor data")
+	}
+
+	return bson.M{"$or": conditions}, nil
+}
+
+// decodePaginationCursor decodes the next or prev cursor of the pagination and checks that it was
+// built for the given sort keys. It returns a nil cursor when the pagination carries none.
+func decodePaginationCursor(pagination *types.Pagination, keys []sortKey) (*Cursor, error) {
+	if pagination.GetNext() != "" && pagination.GetPrev() != "" {
+		return nil, fmt.Errorf("only one of next or prev cursor can be set")
+	}
+
+	encoded := pagination.GetNext()
+	if encoded == "" {
+		encoded = pagination.GetPrev()
+	}
+	if encoded == "" {
+		return nil, nil
+	}
+
+	cursor, err := DecodeCursor(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cursor.Fields) != len(keys) {
+		return nil, fmt.Errorf("cursor does not match the requested sort")
+	}
+	for i, key := range keys {
+		if cursor.Fields[i] != key.Field {
+			return nil, fmt.Errorf("cursor does not match the requested sort")
+		}
+	}
+
+	return cursor, nil
+}
+
+// newCursorFromDocument reads the sort keys from a document and encodes them as a cursor.
+// The document is marshalled with its bson tags so dotted paths resolve the same way MongoDB does.
+// Every sort field must be present in the document, as a null value when it has none: a missing field,
+// such as one left out by the projection or by an omitempty tag, would make the next page start over.
+func newCursorFromDocument(document interface{}, keys []sortKey) (string, error) {
+	raw, err := bson.Marshal(document)
+	if err != nil {
+		return "", fmt.Errorf("unable to read cursor values: %w", err)
+	}
+
+	cursor := Cursor{}
+	for _, key := range keys {
+		var value interface{}
+
+		rawValue, err := bson.Raw(raw).LookupErr(strings.Split(key.Field, ".")...)
+		if err != nil {
+			return "", fmt.Errorf("unable to read cursor value for %s: the field is missing from the document", key.Field)
+		}
+		if err := rawValue.Unmarshal(&value); err != nil {
+			return "", fmt.Errorf("unable to read cursor value for %s: %w", key.Field, err)
+		}
+
+		cursor.Fields = append(cursor.Fields, key.Field)
+		cursor.Values = append(cursor.Values, value)
+	}
+
+	return cursor.Encode()
+}
+
+// isBackward reports whether the pagination requests the page before a prev cursor.
+func isBackward(pagination *types.Pagination) bool {
+	return pagination.GetPrev() != "" && pagination.GetNext() == ""
+}
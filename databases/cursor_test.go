@@ -0,0 +1,149 @@
+package databases
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/educolog9/packages/enums"
+	"github.com/educolog9/packages/types"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func encodeTestCursor(t *testing.T, fields []string, values ...interface{}) string {
+	t.Helper()
+
+	encoded, err := (&Cursor{Fields: fields, Values: values}).Encode()
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	return encoded
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := &Cursor{Fields: []string{"name", "age", "_id"}, Values: []interface{}{nil, int32(30), "a"}}
+
+	encoded, err := cursor.Encode()
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	decoded, err := DecodeCursor(encoded)
+	if err != nil {
+		t.Fatalf("DecodeCursor() error = %v", err)
+	}
+	if !reflect.DeepEqual(decoded, cursor) {
+		t.Errorf("DecodeCursor() = %#v, want %#v", decoded, cursor)
+	}
+
+	for _, invalid := range []string{"not base64!", "e30="} {
+		if _, err := DecodeCursor(invalid); err == nil {
+			t.Errorf("DecodeCursor(%q) error = nil, want an error", invalid)
+		}
+	}
+}
+
+func TestBuildCursorFilter(t *testing.T) {
+	byAge := []types.SortField{{Field: "age", Order: enums.Asc}}
+	byAgeDesc := []types.SortField{{Field: "age", Order: enums.Desc}}
+	fields := []string{"age", "_id"}
+
+	tests := []struct {
+		name    string
+		sorts   []types.SortField
+		next    string
+		prev    string
+		want    bson.M
+		wantErr bool
+	}{
+		{
+			name:  "no cursor",
+			sorts: byAge,
+			want:  nil,
+		},
+		{
+			name:  "next cursor ascending",
+			sorts: byAge,
+			next:  encodeTestCursor(t, fields, int32(30), "a"),
+			want: bson.M{"$or": bson.A{
+				bson.M{"age": bson.M{"$gt": int32(30)}},
+				bson.M{"age": bson.M{"$eq": int32(30)}, "_id": bson.M{"$gt": "a"}},
+			}},
+		},
+		{
+			name:  "next cursor descending keeps nulls after the boundary",
+			sorts: byAgeDesc,
+			next:  encodeTestCursor(t, fields, int32(30), "a"),
+			want: bson.M{"$or": bson.A{
+				bson.M{"$or": bson.A{bson.M{"age": bson.M{"$lt": int32(30)}}, bson.M{"age": nil}}},
+				bson.M{"age": bson.M{"$eq": int32(30)}, "_id": bson.M{"$gt": "a"}},
+			}},
+		},
+		{
+			name:  "null boundary ascending",
+			sorts: byAge,
+			next:  encodeTestCursor(t, fields, nil, "a"),
+			want: bson.M{"$or": bson.A{
+				bson.M{"age": bson.M{"$ne": nil}},
+				bson.M{"age": nil, "_id": bson.M{"$gt": "a"}},
+			}},
+		},
+		{
+			name:  "null boundary descending",
+			sorts: byAgeDesc,
+			next:  encodeTestCursor(t, fields, nil, "a"),
+			want: bson.M{"$or": bson.A{
+				bson.M{"age": nil, "_id": bson.M{"$gt": "a"}},
+			}},
+		},
+		{
+			name:  "prev cursor reverses the orders",
+			sorts: byAge,
+			prev:  encodeTestCursor(t, fields, int32(30), "a"),
+			want: bson.M{"$or": bson.A{
+				bson.M{"$or": bson.A{bson.M{"age": bson.M{"$lt": int32(30)}}, bson.M{"age": nil}}},
+				bson.M{"age": bson.M{"$eq": int32(30)}, "$or": bson.A{bson.M{"_id": bson.M{"$lt": "a"}}, bson.M{"_id": nil}}},
+			}},
+		},
+		{
+			name:  "prev cursor with a null boundary",
+			sorts: byAge,
+			prev:  encodeTestCursor(t, fields, nil, "a"),
+			want: bson.M{"$or": bson.A{
+				bson.M{"age": nil, "$or": bson.A{bson.M{"_id": bson.M{"$lt": "a"}}, bson.M{"_id": nil}}},
+			}},
+		},
+		{
+			name:    "cursor of another sort",
+			sorts:   byAge,
+			next:    encodeTestCursor(t, []string{"name", "_id"}, "x", "a"),
+			wantErr: true,
+		},
+		{
+			name:    "next and prev cursors",
+			sorts:   byAge,
+			next:    encodeTestCursor(t, fields, int32(30), "a"),
+			prev:    encodeTestCursor(t, fields, int32(30), "a"),
+			wantErr: true,
+		},
+		{
+			name:    "malformed cursor",
+			sorts:   byAge,
+			next:    "not a cursor",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := types.NewPaginationConfig(&types.Pagination{Sorts: tt.sorts, Next: tt.next, Prev: tt.prev})
+
+			got, err := buildCursorFilter(config, getSortKeys(config))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildCursorFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildCursorFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
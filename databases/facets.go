@@ -0,0 +1,87 @@
+package databases
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/educolog9/packages/enums"
+	"github.com/educolog9/packages/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// FacetOtherBucket is the value of the bucket counting the values outside the boundaries of a buckets facet.
+const FacetOtherBucket = "other"
+
+// buildMongoFacets returns the sub-pipelines of the facets of the pagination, keyed by facet name, in the
+// order they were requested. Date histograms are truncated in the location of the config.
+func buildMongoFacets(config *types.PaginationConfig) (bson.D, error) {
+	var facets bson.D
+	seen := map[string]bool{}
+
+	for _, f := range config.Pagination.GetFacets() {
+		if seen[f.Name] {
+			return nil, fmt.Errorf("duplicate facet %s", f.Name)
+		}
+		seen[f.Name] = true
+
+		pipeline, err := buildMongoFacetPipeline(f, config.GetLocation())
+		if err != nil {
+			return nil, err
+		}
+		facets = append(facets, bson.E{Key: f.Name, Value: pipeline})
+	}
+
+	return facets, nil
+}
+
+// buildMongoFacetPipeline returns the sub-pipeline counting the values of a facet. Every sub-pipeline outputs
+// documents with the value in _id and the number of documents in count, so they decode into types.FacetBucket.
+// Terms facets count the elements of array fields separately, and are sorted by count and then by value.
+// Date histograms start weeks on Monday, as startOfWeek does, and are truncated in UTC when the location is nil.
+func buildMongoFacetPipeline(f types.FacetRequest, location *time.Location) (mongo.Pipeline, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+
+	path := "$" + f.Field
+	count := bson.D{{Key: "$sum", Value: 1}}
+
+	switch f.Type {
+	case enums.Terms:
+		return mongo.Pipeline{
+			{{Key: "$unwind", Value: bson.D{{Key: "path", Value: path}, {Key: "preserveNullAndEmptyArrays", Value: true}}}},
+			{{Key: "$group", Value: bson.D{{Key: "_id", Value: path}, {Key: "count", Value: count}}}},
+			{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+			{{Key: "$limit", Value: f.GetSize()}},
+		}, nil
+	case enums.Buckets:
+		boundaries := make(bson.A, len(f.Boundaries))
+		for i, boundary := range f.Boundaries {
+			boundaries[i] = boundary
+		}
+		return mongo.Pipeline{
+			{{Key: "$bucket", Value: bson.D{
+				{Key: "groupBy", Value: path},
+				{Key: "boundaries", Value: boundaries},
+				{Key: "default", Value: FacetOtherBucket},
+				{Key: "output", Value: bson.D{{Key: "count", Value: count}}},
+			}}},
+		}, nil
+	case enums.DateHistogram:
+		trunc := bson.D{{Key: "date", Value: path}, {Key: "unit", Value: f.Interval}}
+		if location != nil {
+			trunc = append(trunc, bson.E{Key: "timezone", Value: location.String()})
+		}
+		if f.Interval == "week" {
+			trunc = append(trunc, bson.E{Key: "startOfWeek", Value: "monday"})
+		}
+		return mongo.Pipeline{
+			{{Key: "$match", Value: bson.D{{Key: f.Field, Value: bson.D{{Key: "$type", Value: "date"}}}}}},
+			{{Key: "$group", Value: bson.D{{Key: "_id", Value: bson.D{{Key: "$dateTrunc", Value: trunc}}}, {Key: "count", Value: count}}}},
+			{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+		}, nil
+	default:
+		return nil, fmt.Errorf("invalid type %q for facet %s", f.Type, f.Name)
+	}
+}
@@ -0,0 +1,94 @@
+package databases
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/educolog9/packages/enums"
+	"github.com/educolog9/packages/types"
+)
+
+// resolvePaginationDates returns a copy of the config whose date filter values are resolved into BSON
+// dates at the current time in the location of the config, so relative expressions such as "startOfDay"
+// follow the time zone of the request. Only filters typed as date or datetime, by their type hint or by the
+// type of their field in the PaginationSchema, are resolved; untyped values are left as they are, so a
+// string such as "today" is never compared as a date.
+func resolvePaginationDates(config *types.PaginationConfig) (*types.PaginationConfig, error) {
+	filters, err := resolveFilterDates(config.Pagination.GetFilters(), time.Now().In(config.GetLocation()))
+	if err != nil {
+		return nil, err
+	}
+
+	pagination := *config.Pagination
+	pagination.Filters = filters
+
+	resolved := *config
+	resolved.Pagination = &pagination
+
+	return &resolved, nil
+}
+
+func resolveFilterDates(filters []types.Filter, now time.Time) ([]types.Filter, error) {
+	if filters == nil {
+		return nil, nil
+	}
+
+	resolved := make([]types.Filter, len(filters))
+	for i, f := range filters {
+		if f.IsGroup() {
+			children, err := resolveFilterDates(f.Filters, now)
+			if err != nil {
+				return nil, err
+			}
+			f.Filters = children
+			resolved[i] = f
+			continue
+		}
+
+		if f.Operator == enums.ElemMatch {
+			children, err := f.ElemMatchFilters()
+			if err != nil {
+				resolved[i] = f
+				continue
+			}
+			if children, err = resolveFilterDates(children, now); err != nil {
+				return nil, err
+			}
+			f.Value = children
+			resolved[i] = f
+			continue
+		}
+
+		if f.Type != enums.Date && f.Type != enums.DateTime {
+			resolved[i] = f
+			continue
+		}
+
+		switch f.Operator {
+		case enums.Equal, enums.NotEqual, enums.GreaterThan, enums.GreaterThanOrEqual, enums.LessThan, enums.LessThanOrEqual:
+			value, err := types.FilterValueToDateTime(f.Value, f.Type, now)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for field %s: %w", f.Field, err)
+			}
+			f.Value = value
+		case enums.In, enums.NotIn, enums.Between, enums.All:
+			list, err := types.FilterValueToList(f.Value)
+			if err != nil {
+				break
+			}
+			values := make([]interface{}, len(list))
+			for j, item := range list {
+				value, err := types.FilterValueToDateTime(item, f.Type, now)
+				if err != nil {
+					return nil, fmt.Errorf("invalid value for field %s at position %d: %w", f.Field, j, err)
+				}
+				values[j] = value
+			}
+			f.Value = values
+		}
+
+		resolved[i] = f
+	}
+
+	return resolved, nil
+}
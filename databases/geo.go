@@ -0,0 +1,141 @@
+package databases
+
+import (
+	"fmt"
+
+	"github.com/educolog9/packages/enums"
+	"github.com/educolog9/packages/types"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// GeoNearDistanceField is the field where the $geoNear stage generated for near filters stores the
+// distance in meters between each document and the requested point.
+const GeoNearDistanceField = "distance"
+
+// earthRadiusMeters is the equatorial radius used to convert distances to radians for $centerSphere.
+const earthRadiusMeters = 6378100.0
+
+// buildMongoGeoCondition translates the geo operators into their MongoDB query operators:
+// near into $nearSphere, and withinRadius, withinBox and withinPolygon into $geoWithin.
+// Coordinates are accepted as {"lat":..,"lng":..} objects or GeoJSON, and validated with the
+// same rules as the "latitude" and "longitude" validation tags.
+func buildMongoGeoCondition(f types.Filter) (bson.M, error) {
+	switch f.Operator {
+	case enums.Near:
+		near, err := types.ParseGeoNear(f.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for '%s' operator: %w", f.Operator, err)
+		}
+		nearSphere := bson.M{"$geometry": buildGeoJSONPoint(near.Point)}
+		if near.MaxDistance > 0 {
+			nearSphere["$maxDistance"] = near.MaxDistance
+		}
+		if near.MinDistance > 0 {
+			nearSphere["$minDistance"] = near.MinDistance
+		}
+		return bson.M{"$nearSphere": nearSphere}, nil
+	case enums.WithinRadius:
+		point, radius, err := types.ParseGeoCircle(f.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for '%s' operator: %w", f.Operator, err)
+		}
+		return bson.M{"$geoWithin": bson.M{"$centerSphere": bson.A{point.Coordinates(), radius / earthRadiusMeters}}}, nil
+	case enums.WithinBox:
+		bottomLeft, topRight, err := types.ParseGeoBox(f.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for '%s' operator: %w", f.Operator, err)
+		}
+		return bson.M{"$geoWithin": bson.M{"$geometry": buildGeoJSONBox(bottomLeft, topRight)}}, nil
+	case enums.WithinPolygon:
+		ring, err := types.ParseGeoPolygon(f.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for '%s' operator: %w", f.Operator, err)
+		}
+		return bson.M{"$geoWithin": bson.M{"$geometry": bson.M{"type": "Polygon", "coordinates": [][][]float64{ring}}}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported operator %s", f.Operator)
+	}
+}
+
+// buildMongoGeoNearStage builds the $geoNear stage used by the pipeline converters for a near filter.
+// The query holds the rest of the filters, since $geoNear must be the first stage of the pipeline.
+func buildMongoGeoNearStage(f types.Filter, query bson.M) (bson.D, error) {
+	near, err := types.ParseGeoNear(f.Value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for '%s' operator: %w", f.Operator, err)
+	}
+
+	geoNear := bson.D{
+		{Key: "near", Value: buildGeoJSONPoint(near.Point)},
+		{Key: "distanceField", Value: GeoNearDistanceField},
+		{Key: "key", Value: f.Field},
+		{Key: "spherical", Value: true},
+	}
+	if near.MaxDistance > 0 {
+		geoNear = append(geoNear, bson.E{Key: "maxDistance", Value: near.MaxDistance})
+	}
+	if near.MinDistance > 0 {
+		geoNear = append(geoNear, bson.E{Key: "minDistance", Value: near.MinDistance})
+	}
+	if len(query) > 0 {
+		geoNear = append(geoNear, bson.E{Key: "query", Value: query})
+	}
+
+	return bson.D{{Key: "$geoNear", Value: geoNear}}, nil
+}
+
+// splitMongoGeoNearFilter returns the top-level near filter of the config, if any, and a copy of
+// the config without it. Only one near filter is allowed.
+func splitMongoGeoNearFilter(config *types.PaginationConfig) (*types.Filter, *types.PaginationConfig, error) {
+	var near *types.Filter
+	var remaining []types.Filter
+
+	for i, f := range config.Pagination.GetFilters() {
+		if !f.IsGroup() && f.Operator == enums.Near {
+			if near != nil {
+				return nil, nil, fmt.Errorf("only one 'near' filter is allowed")
+			}
+			near = &config.Pagination.GetFilters()[i]
+			continue
+		}
+		remaining = append(remaining, f)
+	}
+
+	if near == nil {
+		return nil, config, nil
+	}
+
+	pagination := *config.Pagination
+	pagination.Filters = remaining
+
+	nearConfig := *config
+	nearConfig.Pagination = &pagination
+
+	return near, &nearConfig, nil
+}
+
+// buildGeoJSONBox returns the GeoJSON Polygon of a box. A box crossing the antimeridian is split there into
+// a MultiPolygon of the part east of bottomLeft and the part west of topRight.
+func buildGeoJSONBox(bottomLeft types.GeoPoint, topRight types.GeoPoint) bson.M {
+	if bottomLeft.Longitude < topRight.Longitude {
+		return bson.M{"type": "Polygon", "coordinates": [][][]float64{buildGeoBoxRing(bottomLeft.Longitude, topRight.Longitude, bottomLeft.Latitude, topRight.Latitude)}}
+	}
+
+	var polygons [][][][]float64
+	if bottomLeft.Longitude < 180 {
+		polygons = append(polygons, [][][]float64{buildGeoBoxRing(bottomLeft.Longitude, 180, bottomLeft.Latitude, topRight.Latitude)})
+	}
+	if topRight.Longitude > -180 {
+		polygons = append(polygons, [][][]float64{buildGeoBoxRing(-180, topRight.Longitude, bottomLeft.Latitude, topRight.Latitude)})
+	}
+	return bson.M{"type": "MultiPolygon", "coordinates": polygons}
+}
+
+// buildGeoBoxRing returns the closed ring of the box between the given longitudes and latitudes.
+func buildGeoBoxRing(west float64, east float64, south float64, north float64) [][]float64 {
+	return [][]float64{{west, south}, {east, south}, {east, north}, {west, north}, {west, south}}
+}
+
+func buildGeoJSONPoint(point types.GeoPoint) bson.M {
+	return bson.M{"type": "Point", "coordinates": point.Coordinates()}
+}
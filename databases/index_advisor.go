@@ -0,0 +1,374 @@
+package databases
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/educolog9/packages/enums"
+	"github.com/educolog9/packages/types"
+	"github.com/opentracing/opentracing-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// IndexDeclaration declares how the documents of a collection are queried, so the indexes they need can be computed.
+type IndexDeclaration struct {
+	// Schema is the allowlist of fields the endpoints of the collection accept. Every filterable field gets
+	// an index on its path, every sortable field an index on its path followed by the _id tiebreaker, and
+	// filterable fields allowing geo operators a 2dsphere index, in place of the index on its path when
+	// they allow only geo operators.
+	Schema *types.PaginationSchema
+	// TenantField is the field holding the organization, e.g. types.DefaultOrganizationField, when the
+	// queries are scoped with a Tenant. It is the first key of every index.
+	TenantField string
+	// Queries are the combinations of filters and sorts used together, which get one compound index each.
+	Queries []IndexQuery
+	// Collation is the collation the queries run with, as returned by BuildMongoCollation, or nil when they
+	// run without one. Queries only use indexes with the same collation, so the indexes are created with it.
+	Collation *options.Collation
+}
+
+// IndexQuery is a combination of filters and sorts used together, with API field names of the schema.
+type IndexQuery struct {
+	// Equality are the fields filtered with eq or in.
+	Equality []string
+	// Sort are the fields the query is sorted by.
+	Sort []types.SortField
+	// Range are the fields filtered with gt, gte, lt, lte, ne, like or other range operators.
+	Range []string
+}
+
+// IndexSuggestion is an index needed by the queries of an IndexDeclaration.
+type IndexSuggestion struct {
+	// Name is the name MongoDB gives to an index with these keys, e.g. "organization_1_createdAt_-1".
+	Name string
+	// Keys are the keys of the index, in order.
+	Keys bson.D
+	// Reason describes the query that needs the index.
+	Reason string
+}
+
+// IndexReport is the result of EnsureIndexes.
+type IndexReport struct {
+	// Required are the indexes needed by the declaration.
+	Required []IndexSuggestion
+	// Missing are the required indexes not covered by any index of the collection.
+	Missing []IndexSuggestion
+	// Created are the names of the indexes created, which is empty in dry-run mode.
+	Created []string
+}
+
+// AdviseIndexes returns the indexes needed by the queries of a declaration. Compound indexes follow the
+// ESR rule: the equality fields first, then the sort fields, then the range fields. The tenant field is
+// prepended as an equality field, and sorted indexes end with the _id tiebreaker added by the converters.
+// Indexes that are a prefix of another suggested index are left out, since the longer index serves them.
+func AdviseIndexes(declaration *IndexDeclaration) []IndexSuggestion {
+	var suggestions []IndexSuggestion
+
+	for _, query := range declaration.Queries {
+		var keys bson.D
+		var reason []string
+
+		for _, name := range query.Equality {
+			keys = appendIndexKey(keys, resolveIndexPath(declaration.Schema, name), 1)
+		}
+		if len(query.Equality) > 0 {
+			reason = append(reason, "equality on "+strings.Join(query.Equality, ", "))
+		}
+
+		if len(query.Sort) > 0 {
+			var sorts []string
+			for _, sortField := range query.Sort {
+				direction := 1
+				if sortField.GetOrder() == enums.Desc {
+					direction = -1
+				}
+				keys = appendIndexKey(keys, resolveIndexPath(declaration.Schema, sortField.Field), direction)
+				sorts = append(sorts, sortField.Field)
+			}
+			keys = appendIndexKey(keys, "_id", 1)
+			reason = append(reason, "sort on "+strings.Join(sorts, ", "))
+		}
+
+		for _, name := range query.Range {
+			keys = appendIndexKey(keys, resolveIndexPath(declaration.Schema, name), 1)
+		}
+		if len(query.Range) > 0 {
+			reason = append(reason, "range on "+strings.Join(query.Range, ", "))
+		}
+
+		suggestions = appendIndexSuggestion(suggestions, declaration, keys, strings.Join(reason, ", "))
+	}
+
+	if declaration.Schema != nil {
+		for _, name := range sortedSchemaFields(declaration.Schema) {
+			field, _ := declaration.Schema.GetField(name)
+			path := field.GetPath(name)
+
+			if field.Filterable && hasGeoOperator(field) {
+				suggestions = appendIndexSuggestion(suggestions, declaration, bson.D{{Key: path, Value: "2dsphere"}}, "geo filters on "+name)
+			}
+			if field.Filterable && !isGeoOnlyField(field) {
+				suggestions = appendIndexSuggestion(suggestions, declaration, bson.D{{Key: path, Value: 1}}, "filters on "+name)
+			}
+			if field.Sortable {
+				suggestions = appendIndexSuggestion(suggestions, declaration, bson.D{{Key: path, Value: 1}, {Key: "_id", Value: 1}}, "sort on "+name)
+			}
+		}
+	}
+
+	var advised []IndexSuggestion
+	for i, suggestion := range suggestions {
+		covered := false
+		for j, other := range suggestions {
+			if i != j && len(other.Keys) > len(suggestion.Keys) && isIndexPrefix(suggestion.Keys, other.Keys) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			advised = append(advised, suggestion)
+		}
+	}
+
+	return advised
+}
+
+// EnsureIndexes compares the indexes needed by a declaration with the indexes of the collection, and creates
+// the missing ones, with the collation of the declaration, unless dryRun is set. An existing index covers a
+// required one when the required keys are a prefix of its keys, with the same or all reversed directions,
+// it has the collation of the declaration, and it is not a partial index, since a partial index is not used
+// by queries whose filters do not imply its filter expression.
+// It is meant to run at startup; in dry-run mode the report lists the indexes that would be created.
+func EnsureIndexes(ctx context.Context, collection *mongo.Collection, declaration *IndexDeclaration, dryRun bool) (*IndexReport, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "EnsureIndexes")
+	defer span.Finish()
+
+	report := &IndexReport{Required: AdviseIndexes(declaration)}
+
+	cursor, err := collection.Indexes().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the indexes of %s: %w", collection.Name(), err)
+	}
+
+	var live []struct {
+		Key                     bson.D          `bson:"key"`
+		Collation               *indexCollation `bson:"collation"`
+		PartialFilterExpression bson.Raw        `bson:"partialFilterExpression"`
+	}
+	if err := cursor.All(ctx, &live); err != nil {
+		return nil, fmt.Errorf("failed to list the indexes of %s: %w", collection.Name(), err)
+	}
+
+	for _, suggestion := range report.Required {
+		covered := false
+		for _, index := range live {
+			if index.PartialFilterExpression == nil && isSameCollation(declaration.Collation, index.Collation) && isIndexPrefix(suggestion.Keys, index.Key) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			report.Missing = append(report.Missing, suggestion)
+		}
+	}
+
+	if dryRun || len(report.Missing) == 0 {
+		return report, nil
+	}
+
+	models := make([]mongo.IndexModel, len(report.Missing))
+	for i, suggestion := range report.Missing {
+		models[i] = mongo.IndexModel{Keys: suggestion.Keys}
+		if declaration.Collation != nil {
+			models[i].Options = options.Index().SetCollation(declaration.Collation)
+		}
+	}
+
+	created, err := collection.Indexes().CreateMany(ctx, models)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the indexes of %s: %w", collection.Name(), err)
+	}
+	report.Created = created
+
+	return report, nil
+}
+
+// appendIndexSuggestion adds an index with the tenant field of the declaration prepended to its keys,
+// unless an index with the same keys was already suggested.
+func appendIndexSuggestion(suggestions []IndexSuggestion, declaration *IndexDeclaration, keys bson.D, reason string) []IndexSuggestion {
+	if declaration.TenantField != "" {
+		keys = append(bson.D{{Key: declaration.TenantField, Value: 1}}, keys...)
+		var deduplicated bson.D
+		for _, key := range keys {
+			deduplicated = appendIndexKey(deduplicated, key.Key, key.Value)
+		}
+		keys = deduplicated
+	}
+	if len(keys) == 0 {
+		return suggestions
+	}
+
+	for _, suggestion := range suggestions {
+		if len(suggestion.Keys) == len(keys) && isIndexPrefix(keys, suggestion.Keys) {
+			return suggestions
+		}
+	}
+
+	return append(suggestions, IndexSuggestion{Name: buildIndexName(keys), Keys: keys, Reason: reason})
+}
+
+// appendIndexKey adds a key to an index, unless the index already has it, since only the first
+// occurrence of a field in a compound index is useful.
+func appendIndexKey(keys bson.D, path string, value interface{}) bson.D {
+	for _, key := range keys {
+		if key.Key == path {
+			return keys
+		}
+	}
+	return append(keys, bson.E{Key: path, Value: value})
+}
+
+// isIndexPrefix checks if the keys are a prefix of the keys of an index, with the same directions or
+// all of them reversed, since an index can be walked in both directions.
+func isIndexPrefix(keys bson.D, index bson.D) bool {
+	if len(keys) > len(index) {
+		return false
+	}
+
+	same, reversed := true, true
+	for i, key := range keys {
+		if key.Key != index[i].Key {
+			return false
+		}
+		direction, isNumber := indexDirection(key.Value)
+		indexDir, isIndexNumber := indexDirection(index[i].Value)
+		if !isNumber || !isIndexNumber {
+			if fmt.Sprint(key.Value) != fmt.Sprint(index[i].Value) {
+				return false
+			}
+			continue
+		}
+		same = same && direction == indexDir
+		reversed = reversed && direction == -indexDir
+	}
+
+	return same || reversed
+}
+
+// indexDirection reads the direction of an index key, which the server returns as an int32, int64 or double.
+func indexDirection(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return indexSign(float64(v)), true
+	case int32:
+		return indexSign(float64(v)), true
+	case int64:
+		return indexSign(float64(v)), true
+	case float64:
+		return indexSign(v), true
+	default:
+		return 0, false
+	}
+}
+
+func indexSign(value float64) int {
+	if value < 0 {
+		return -1
+	}
+	return 1
+}
+
+// buildIndexName returns the name MongoDB generates for an index with the given keys.
+func buildIndexName(keys bson.D) string {
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s_%v", key.Key, key.Value))
+	}
+	return strings.Join(parts, "_")
+}
+
+// resolveIndexPath returns the document path of a field of the schema, or the name itself when the field is not declared.
+func resolveIndexPath(schema *types.PaginationSchema, name string) string {
+	if schema == nil {
+		return name
+	}
+	if field, ok := schema.GetField(name); ok {
+		return field.GetPath(name)
+	}
+	return name
+}
+
+// indexCollation is the collation of an index, as listed by the server.
+type indexCollation struct {
+	Locale    string `bson:"locale"`
+	Strength  int    `bson:"strength"`
+	CaseLevel bool   `bson:"caseLevel"`
+}
+
+// isSameCollation checks if an index has the collation the queries run with. The simple collation, which
+// compares strings byte by byte, is the same as no collation, and a zero strength is the default strength 3.
+func isSameCollation(collation *options.Collation, index *indexCollation) bool {
+	if collation == nil || collation.Locale == "simple" {
+		return index == nil || index.Locale == "simple"
+	}
+	if index == nil {
+		return false
+	}
+
+	strength, indexStrength := collation.Strength, index.Strength
+	if strength == 0 {
+		strength = 3
+	}
+	if indexStrength == 0 {
+		indexStrength = 3
+	}
+
+	return collation.Locale == index.Locale && strength == indexStrength && collation.CaseLevel == index.CaseLevel
+}
+
+// isGeoOperator checks if an operator is a geo operator, which needs a 2dsphere index.
+func isGeoOperator(operator enums.Operator) bool {
+	switch operator {
+	case enums.Near, enums.WithinRadius, enums.WithinBox, enums.WithinPolygon:
+		return true
+	default:
+		return false
+	}
+}
+
+// hasGeoOperator checks if a field explicitly allows a geo operator, so it needs a 2dsphere index.
+func hasGeoOperator(field types.FieldSchema) bool {
+	for _, operator := range field.Operators {
+		if isGeoOperator(operator) {
+			return true
+		}
+	}
+	return false
+}
+
+// isGeoOnlyField checks if a field only allows geo operators, so it needs no index on its path.
+func isGeoOnlyField(field types.FieldSchema) bool {
+	if len(field.Operators) == 0 {
+		return false
+	}
+	for _, operator := range field.Operators {
+		if !isGeoOperator(operator) {
+			return false
+		}
+	}
+	return true
+}
+
+// sortedSchemaFields returns the names of the fields of the schema in alphabetical order, so suggestions are stable.
+func sortedSchemaFields(schema *types.PaginationSchema) []string {
+	names := make([]string, 0, len(schema.Fields))
+	for name := range schema.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,125 @@
+package databases
+
+import (
+	"strings"
+
+	"github.com/educolog9/packages/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// mongoTextLanguages are the languages supported by MongoDB text search, by ISO 639-1 code.
+// The same codes are used as collation locales.
+var mongoTextLanguages = map[string]bool{
+	"da": true,
+	"de": true,
+	"en": true,
+	"es": true,
+	"fi": true,
+	"fr": true,
+	"hu": true,
+	"it": true,
+	"nb": true,
+	"nl": true,
+	"pt": true,
+	"ro": true,
+	"ru": true,
+	"sv": true,
+	"tr": true,
+}
+
+// resolveMongoLanguage returns the ISO 639-1 code of the language of the config, reading the primary subtag of
+// tags such as "es-DO", or an empty string when no language is set or MongoDB text search does not support it.
+func resolveMongoLanguage(config *types.PaginationConfig) string {
+	language := strings.ToLower(strings.TrimSpace(config.Language))
+	if i := strings.IndexAny(language, "-_,;"); i >= 0 {
+		language = language[:i]
+	}
+
+	if !mongoTextLanguages[language] {
+		return ""
+	}
+	return language
+}
+
+// buildMongoTextSearch returns the $text condition of the search text of the pagination, in the language of
+// the config and with the case and diacritic sensitivity of its text search options.
+func buildMongoTextSearch(config *types.PaginationConfig) bson.M {
+	text := bson.M{"$search": config.Pagination.GetSearch()}
+
+	if language := resolveMongoLanguage(config); language != "" {
+		text["$language"] = language
+	}
+
+	textSearch := config.GetTextSearch()
+	if textSearch.CaseSensitive {
+		text["$caseSensitive"] = true
+	}
+	if textSearch.DiacriticSensitive {
+		text["$diacriticSensitive"] = true
+	}
+
+	return text
+}
+
+// BuildMongoCollation returns the collation of the language of the config, or nil when no supported language
+// is set or the text search options do not enable the collation. Strings are compared by base letter only
+// unless the text search options are case or diacritic sensitive, so equality filters and sorts follow the
+// same rules as the $text search.
+// Queries only use indexes created with the same collation, so collections queried with it should declare
+// it as their default collation or create their indexes with it.
+func BuildMongoCollation(config *types.PaginationConfig) *options.Collation {
+	language := resolveMongoLanguage(config)
+	if language == "" || !config.GetTextSearch().Collation {
+		return nil
+	}
+
+	textSearch := config.GetTextSearch()
+
+	collation := &options.Collation{Locale: language}
+	switch {
+	case textSearch.CaseSensitive && textSearch.DiacriticSensitive:
+		collation.Strength = 3
+	case textSearch.DiacriticSensitive:
+		collation.Strength = 2
+	case textSearch.CaseSensitive:
+		collation.Strength = 1
+		collation.CaseLevel = true
+	default:
+		collation.Strength = 1
+	}
+
+	return collation
+}
+
+// newMongoCollator returns a collator comparing strings as MongoDB does with the collation, or nil when
+// there is no collation and strings are compared byte by byte.
+func newMongoCollator(collation *options.Collation) *collate.Collator {
+	if collation == nil {
+		return nil
+	}
+
+	var collateOptions []collate.Option
+	switch {
+	case collation.Strength == 1 && collation.CaseLevel:
+		collateOptions = append(collateOptions, collate.IgnoreDiacritics)
+	case collation.Strength == 1:
+		collateOptions = append(collateOptions, collate.IgnoreCase, collate.IgnoreDiacritics)
+	case collation.Strength == 2:
+		collateOptions = append(collateOptions, collate.IgnoreCase)
+	}
+
+	return collate.New(language.Make(collation.Locale), collateOptions...)
+}
+
+// NewMongoAggregateOptions returns the options of an aggregation of the pipelines built from the config,
+// with the collation of its language. See BuildMongoCollation.
+func NewMongoAggregateOptions(config *types.PaginationConfig) *options.AggregateOptions {
+	aggregateOptions := options.Aggregate()
+	if collation := BuildMongoCollation(config); collation != nil {
+		aggregateOptions.SetCollation(collation)
+	}
+	return aggregateOptions
+}
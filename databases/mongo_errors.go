@@ -0,0 +1,145 @@
+package databases
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	customerrors "github.com/educolog9/packages/errors/custom_errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+)
+
+// mongoDocumentValidationFailure is the server error code of a write rejected by the JSON schema of a collection.
+const mongoDocumentValidationFailure = 121
+
+// mongoDuplicateKeyPattern reads the index and the duplicated key of an E11000 error message, e.g.
+// `E11000 duplicate key error collection: app.users index: email_1 dup key: { email: "a@b.com" }`.
+var mongoDuplicateKeyPattern = regexp.MustCompile(`index: (\S+) dup key: (\{.*\})`)
+
+// mongoDuplicateKeyFieldPattern reads the fields of the duplicated key of an E11000 error message.
+var mongoDuplicateKeyFieldPattern = regexp.MustCompile(`([\w.$]+): ("(?:[^"\\]|\\.)*"|[^,}]+)`)
+
+// TranslateMongoError converts an error returned by the mongo driver into the matching custom error,
+// so functions.HandleError produces the same response for it everywhere:
+//
+//	mongo.ErrNoDocuments                     NotFoundError (404)
+//	duplicate key (E11000)                   DuplicateKeyError (409), with the index and duplicated key
+//	document validation failure              ValidationError (400)
+//	unreachable servers or network errors    ServiceUnavailableError (503)
+//	timeouts and expired deadlines           GatewayTimeoutError (504)
+//	anything else                            InternalServerError (500)
+//
+// Custom errors are returned as they are, and a nil error is returned as nil.
+func TranslateMongoError(err error) customerrors.BaseErrorInterface {
+	if err == nil {
+		return nil
+	}
+
+	var custom customerrors.BaseErrorInterface
+	if errors.As(err, &custom) {
+		return custom
+	}
+
+	var serverError mongo.ServerError
+	isServerError := errors.As(err, &serverError)
+
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return customerrors.NewNotFoundError("", err)
+	case mongo.IsDuplicateKeyError(err):
+		return newDuplicateKeyError(err)
+	case isServerError && serverError.HasErrorCode(mongoDocumentValidationFailure):
+		return customerrors.NewValidationError("", err)
+	case errors.As(err, &topology.ServerSelectionError{}), errors.Is(err, mongo.ErrClientDisconnected):
+		return customerrors.NewServiceUnavailableError("", err)
+	case mongo.IsTimeout(err):
+		return customerrors.NewGatewayTimeoutError("", err)
+	case mongo.IsNetworkError(err):
+		return customerrors.NewServiceUnavailableError("", err)
+	default:
+		return customerrors.NewInternalServerError("", err)
+	}
+}
+
+// newDuplicateKeyError returns the DuplicateKeyError of a duplicate key error, with the violated index and
+// the duplicated key read from the server response, or from the error message on servers that do not send them.
+func newDuplicateKeyError(err error) *customerrors.DuplicateKeyError {
+	index, key := parseMongoDuplicateKey(err)
+
+	msg := ""
+	if len(key) > 0 {
+		fields := make([]string, 0, len(key))
+		for field := range key {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		msg = fmt.Sprintf("Duplicate key error: %s", strings.Join(fields, ", "))
+	}
+
+	duplicate := customerrors.NewDuplicateKeyError(msg, err)
+	duplicate.Index = index
+	duplicate.Key = key
+	return duplicate
+}
+
+// parseMongoDuplicateKey returns the index and the duplicated key of the first duplicate key error of err.
+func parseMongoDuplicateKey(err error) (string, map[string]interface{}) {
+	type serverResponse struct {
+		message string
+		raw     bson.Raw
+	}
+
+	var responses []serverResponse
+	var writeException mongo.WriteException
+	if errors.As(err, &writeException) {
+		for _, writeError := range writeException.WriteErrors {
+			responses = append(responses, serverResponse{writeError.Message, writeError.Raw})
+		}
+	}
+	var bulkWriteException mongo.BulkWriteException
+	if errors.As(err, &bulkWriteException) {
+		for _, writeError := range bulkWriteException.WriteErrors {
+			responses = append(responses, serverResponse{writeError.Message, writeError.Raw})
+		}
+	}
+	var commandError mongo.CommandError
+	if errors.As(err, &commandError) {
+		responses = append(responses, serverResponse{commandError.Message, commandError.Raw})
+	}
+	if len(responses) == 0 {
+		responses = append(responses, serverResponse{message: err.Error()})
+	}
+
+	for _, response := range responses {
+		match := mongoDuplicateKeyPattern.FindStringSubmatch(response.message)
+		if match == nil {
+			continue
+		}
+		index := match[1]
+
+		if keyValue, lookupErr := response.raw.LookupErr("keyValue"); lookupErr == nil {
+			var key map[string]interface{}
+			if doc, ok := keyValue.DocumentOK(); ok && bson.Unmarshal(doc, &key) == nil {
+				return index, key
+			}
+		}
+
+		key := map[string]interface{}{}
+		for _, field := range mongoDuplicateKeyFieldPattern.FindAllStringSubmatch(match[2], -1) {
+			value := strings.TrimSpace(field[2])
+			if unquoted, unquoteErr := strconv.Unquote(value); unquoteErr == nil {
+				key[field[1]] = unquoted
+			} else {
+				key[field[1]] = value
+			}
+		}
+		return index, key
+	}
+
+	return "", nil
+}
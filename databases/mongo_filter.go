@@ -0,0 +1,325 @@
+package databases
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/educolog9/packages/enums"
+	"github.com/educolog9/packages/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// buildMongoFilter builds the MongoDB filter shared by the converters: the filter tree of the
+// pagination, with its dates resolved in the location of the config, the organization of the tenant
+// and its $text search, in the language of the config, when withText is set.
+// The cursor range is not included; see buildCursorFilter.
+func buildMongoFilter(config *types.PaginationConfig, withText bool) (bson.M, error) {
+	config, err := resolvePaginationDates(config)
+	if err != nil {
+		return nil, err
+	}
+	pagination := config.Pagination
+
+	field, organization, err := resolveTenantScope(config.Tenant, pagination.GetFilters())
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := buildMongoFilterTree(pagination.GetFilters())
+	if err != nil {
+		return nil, err
+	}
+
+	if field != "" {
+		filter[field] = organization
+	}
+
+	if pagination.GetSearch() != "" && withText {
+		filter["$text"] = buildMongoTextSearch(config)
+	}
+
+	return filter, nil
+}
+
+// buildMongoPageFilter builds the filter of a single page: the filter of buildMongoFilter
+// restricted to the range after the cursor when the pagination carries one.
+func buildMongoPageFilter(config *types.PaginationConfig, sortKeys []sortKey, withText bool) (bson.M, error) {
+	filter, err := buildMongoFilter(config, withText)
+	if err != nil {
+		return nil, err
+	}
+
+	cursorFilter, err := buildCursorFilter(config, sortKeys)
+	if err != nil {
+		return nil, err
+	}
+	if cursorFilter != nil {
+		appendMongoAnd(filter, cursorFilter)
+	}
+
+	return filter, nil
+}
+
+// buildMongoFilterTree translates a list of filters into a MongoDB filter.
+// It supports every operator of enums.Operator, from eq to withinPolygon; like and notLike match the value
+// as an escaped literal substring unless the MatchOptions of the filter enable raw mode.
+// The filters are ANDed: conditions on the same field are merged into one operator document
+// (e.g. {"age": {"$gte": 18, "$lte": 30}}), and groups or repeated operators on the same field
+// are kept apart in an $and list so no condition is lost. And, or and not groups are translated
+// into $and, $or and $nor.
+func buildMongoFilterTree(filters []types.Filter) (bson.M, error) {
+	filter := bson.M{}
+	var and bson.A
+
+	for _, f := range filters {
+		if f.IsGroup() {
+			condition, err := buildMongoGroupCondition(f)
+			if err != nil {
+				return nil, err
+			}
+			and = append(and, condition)
+			continue
+		}
+
+		if f.Field == "" {
+			return nil, fmt.Errorf("missing field for '%s' operator", f.Operator)
+		}
+		if strings.HasPrefix(f.Field, "$") {
+			return nil, fmt.Errorf("invalid field %s", f.Field)
+		}
+
+		operatorCondition, err := buildMongoOperatorCondition(f)
+		if err != nil {
+			return nil, err
+		}
+
+		existing, ok := filter[f.Field].(bson.M)
+		if !ok {
+			filter[f.Field] = operatorCondition
+			continue
+		}
+
+		if hasAnyKey(existing, operatorCondition) {
+			and = append(and, bson.M{f.Field: operatorCondition})
+			continue
+		}
+
+		for key, value := range operatorCondition {
+			existing[key] = value
+		}
+	}
+
+	if len(and) > 0 {
+		filter["$and"] = and
+	}
+
+	return filter, nil
+}
+
+// buildMongoCondition translates a single filter, either a field condition or a group.
+func buildMongoCondition(f types.Filter) (bson.M, error) {
+	if f.IsGroup() {
+		return buildMongoGroupCondition(f)
+	}
+	return buildMongoFilterTree([]types.Filter{f})
+}
+
+// buildMongoGroupCondition translates a filter group into $and, $or or $nor.
+func buildMongoGroupCondition(group types.Filter) (bson.M, error) {
+	if len(group.Filters) == 0 {
+		return nil, fmt.Errorf("empty '%s' filter group", group.Group)
+	}
+
+	if types.ContainsGeoNearFilter(group.Filters) {
+		return nil, fmt.Errorf("'near' filters cannot be nested in filter groups")
+	}
+
+	switch group.Group {
+	case enums.And:
+		return buildMongoFilterTree(group.Filters)
+	case enums.Or:
+		var or bson.A
+		for _, child := range group.Filters {
+			condition, err := buildMongoCondition(child)
+			if err != nil {
+				return nil, err
+			}
+			or = append(or, condition)
+		}
+		return bson.M{"$or": or}, nil
+	case enums.Not:
+		condition, err := buildMongoFilterTree(group.Filters)
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{"$nor": bson.A{condition}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported filter group %s", group.Group)
+	}
+}
+
+// buildMongoOperatorCondition translates a single field filter into its operator document,
+// e.g. {"$gte": 18} for {"field":"age","operator":"gte","value":18}.
+// It returns the error of types.Filter.Validate when the value does not have the shape the operator expects.
+func buildMongoOperatorCondition(f types.Filter) (bson.M, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch f.Operator {
+	case enums.Equal, enums.NotEqual, enums.GreaterThan, enums.GreaterThanOrEqual, enums.LessThan, enums.LessThanOrEqual:
+		value, err := f.ResolveValue()
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{mongoComparisonOperators[f.Operator]: value}, nil
+	case enums.In:
+		values, err := buildMongoListValue(f)
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{"$in": values}, nil
+	case enums.NotIn:
+		values, err := buildMongoListValue(f)
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{"$nin": values}, nil
+	case enums.Like:
+		return bson.M{"$regex": buildMongoTextRegex(f, true, false, "", "")}, nil
+	case enums.NotLike:
+		return bson.M{"$not": buildMongoTextRegex(f, true, false, "", "")}, nil
+	case enums.Exists:
+		exists, err := types.FilterValueToBool(f.Value)
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{"$exists": exists}, nil
+	case enums.Between:
+		bounds, err := f.ResolveList()
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{"$gte": bounds[0], "$lte": bounds[1]}, nil
+	case enums.StartsWith:
+		return bson.M{"$regex": buildMongoTextRegex(f, false, false, "^", "")}, nil
+	case enums.EndsWith:
+		return bson.M{"$regex": buildMongoTextRegex(f, false, false, "", "$")}, nil
+	case enums.Contains:
+		return bson.M{"$regex": buildMongoTextRegex(f, false, true, "", "")}, nil
+	case enums.All:
+		values, err := f.ResolveList()
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{"$all": values}, nil
+	case enums.Size:
+		size, err := types.FilterValueToInt(f.Value)
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{"$size": size}, nil
+	case enums.ElemMatch:
+		return buildMongoElemMatchCondition(f)
+	default:
+		return buildMongoGeoCondition(f)
+	}
+}
+
+// buildMongoElemMatchCondition translates an elemMatch filter whose value is a list of filters.
+// Filters with a field are matched against the fields of each array element, while filters
+// without a field are matched against the element itself (for arrays of scalars).
+func buildMongoElemMatchCondition(f types.Filter) (bson.M, error) {
+	filters, err := f.ElemMatchFilters()
+	if err != nil {
+		return nil, err
+	}
+
+	elementCondition := bson.M{}
+	var fieldFilters []types.Filter
+	for _, child := range filters {
+		if child.IsGroup() || child.Field != "" {
+			fieldFilters = append(fieldFilters, child)
+			continue
+		}
+
+		operatorCondition, err := buildMongoOperatorCondition(child)
+		if err != nil {
+			return nil, err
+		}
+		if hasAnyKey(elementCondition, operatorCondition) {
+			return nil, fmt.Errorf("invalid value for 'elemMatch' operator: operator %s is repeated", child.Operator)
+		}
+		for key, value := range operatorCondition {
+			elementCondition[key] = value
+		}
+	}
+
+	if len(fieldFilters) == 0 {
+		return bson.M{"$elemMatch": elementCondition}, nil
+	}
+
+	condition, err := buildMongoFilterTree(fieldFilters)
+	if err != nil {
+		return nil, err
+	}
+	return bson.M{"$elemMatch": condition}, nil
+}
+
+// buildMongoListValue resolves the value of an in/notIn filter into a list, coercing each element
+// to the filter type. A single scalar value is read as a one-element list.
+// Filters without a type keep the historical behavior: when the first element of the list is a
+// valid ObjectID hex string, the whole list is converted to ObjectIDs and the elements that are
+// not valid ObjectIDs are dropped.
+func buildMongoListValue(f types.Filter) ([]interface{}, error) {
+	values, err := f.ResolveInValues()
+	if err != nil {
+		return nil, err
+	}
+
+	if f.Type != "" || len(values) == 0 {
+		return values, nil
+	}
+
+	if str, ok := values[0].(string); ok {
+		if _, err := primitive.ObjectIDFromHex(str); err == nil {
+			var objectIDs []interface{}
+			for _, item := range values {
+				if str, ok := item.(string); ok {
+					if objID, err := primitive.ObjectIDFromHex(str); err == nil {
+						objectIDs = append(objectIDs, objID)
+					}
+				}
+			}
+			return objectIDs, nil
+		}
+	}
+
+	return values, nil
+}
+
+// mongoComparisonOperators maps the comparison operators to their MongoDB query operators.
+var mongoComparisonOperators = map[enums.Operator]string{
+	enums.Equal:              "$eq",
+	enums.NotEqual:           "$ne",
+	enums.GreaterThan:        "$gt",
+	enums.GreaterThanOrEqual: "$gte",
+	enums.LessThan:           "$lt",
+	enums.LessThanOrEqual:    "$lte",
+}
+
+// appendMongoAnd adds a condition to the $and list of the filter.
+func appendMongoAnd(filter bson.M, condition bson.M) {
+	and, _ := filter["$and"].(bson.A)
+	filter["$and"] = append(and, condition)
+}
+
+func hasAnyKey(a bson.M, b bson.M) bool {
+	for key := range b {
+		if _, ok := a[key]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,179 @@
+package databases
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/educolog9/packages/enums"
+	"github.com/educolog9/packages/types"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestBuildMongoFilterTree(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters []types.Filter
+		want    bson.M
+		wantErr bool
+	}{
+		{
+			name: "conditions on the same field are merged",
+			filters: []types.Filter{
+				{Field: "age", Operator: enums.GreaterThanOrEqual, Value: 18},
+				{Field: "age", Operator: enums.LessThanOrEqual, Value: 30},
+			},
+			want: bson.M{"age": bson.M{"$gte": 18, "$lte": 30}},
+		},
+		{
+			name: "a repeated operator goes to $and",
+			filters: []types.Filter{
+				{Field: "name", Operator: enums.NotEqual, Value: "a"},
+				{Field: "name", Operator: enums.NotEqual, Value: "b"},
+			},
+			want: bson.M{
+				"name": bson.M{"$ne": "a"},
+				"$and": bson.A{bson.M{"name": bson.M{"$ne": "b"}}},
+			},
+		},
+		{
+			name: "and group",
+			filters: []types.Filter{
+				{Group: enums.And, Filters: []types.Filter{
+					{Field: "age", Operator: enums.GreaterThan, Value: 18},
+					{Field: "name", Operator: enums.Equal, Value: "a"},
+				}},
+			},
+			want: bson.M{"$and": bson.A{
+				bson.M{"age": bson.M{"$gt": 18}, "name": bson.M{"$eq": "a"}},
+			}},
+		},
+		{
+			name: "or group next to a field condition",
+			filters: []types.Filter{
+				{Field: "active", Operator: enums.Equal, Value: true},
+				{Group: enums.Or, Filters: []types.Filter{
+					{Field: "age", Operator: enums.LessThan, Value: 18},
+					{Field: "age", Operator: enums.GreaterThan, Value: 65},
+				}},
+			},
+			want: bson.M{
+				"active": bson.M{"$eq": true},
+				"$and": bson.A{bson.M{"$or": bson.A{
+					bson.M{"age": bson.M{"$lt": 18}},
+					bson.M{"age": bson.M{"$gt": 65}},
+				}}},
+			},
+		},
+		{
+			name: "not group",
+			filters: []types.Filter{
+				{Group: enums.Not, Filters: []types.Filter{
+					{Field: "status", Operator: enums.In, Value: []interface{}{"closed", "archived"}},
+				}},
+			},
+			want: bson.M{"$and": bson.A{bson.M{"$nor": bson.A{
+				bson.M{"status": bson.M{"$in": []interface{}{"closed", "archived"}}},
+			}}}},
+		},
+		{
+			name: "nested groups",
+			filters: []types.Filter{
+				{Group: enums.Or, Filters: []types.Filter{
+					{Field: "name", Operator: enums.Equal, Value: "a"},
+					{Group: enums.And, Filters: []types.Filter{
+						{Field: "age", Operator: enums.Between, Value: []interface{}{18, 30}},
+						{Group: enums.Not, Filters: []types.Filter{
+							{Field: "name", Operator: enums.Exists, Value: true},
+						}},
+					}},
+				}},
+			},
+			want: bson.M{"$and": bson.A{bson.M{"$or": bson.A{
+				bson.M{"name": bson.M{"$eq": "a"}},
+				bson.M{
+					"age":  bson.M{"$gte": 18, "$lte": 30},
+					"$and": bson.A{bson.M{"$nor": bson.A{bson.M{"name": bson.M{"$exists": true}}}}},
+				},
+			}}}},
+		},
+		{
+			name: "elemMatch on the fields of the elements",
+			filters: []types.Filter{
+				{Field: "items", Operator: enums.ElemMatch, Value: []types.Filter{
+					{Field: "sku", Operator: enums.Equal, Value: "x"},
+					{Field: "qty", Operator: enums.GreaterThan, Value: 1},
+				}},
+			},
+			want: bson.M{"items": bson.M{"$elemMatch": bson.M{
+				"sku": bson.M{"$eq": "x"},
+				"qty": bson.M{"$gt": 1},
+			}}},
+		},
+		{
+			name: "elemMatch on the elements",
+			filters: []types.Filter{
+				{Field: "scores", Operator: enums.ElemMatch, Value: []types.Filter{
+					{Operator: enums.GreaterThanOrEqual, Value: 80},
+					{Operator: enums.LessThan, Value: 90},
+				}},
+			},
+			want: bson.M{"scores": bson.M{"$elemMatch": bson.M{"$gte": 80, "$lt": 90}}},
+		},
+		{
+			name:    "empty group",
+			filters: []types.Filter{{Group: enums.Or}},
+			wantErr: true,
+		},
+		{
+			name: "near nested in a group",
+			filters: []types.Filter{
+				{Group: enums.And, Filters: []types.Filter{
+					{Field: "location", Operator: enums.Near, Value: map[string]interface{}{"lng": 1, "lat": 2}},
+				}},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported group",
+			filters: []types.Filter{{Group: "xor", Filters: []types.Filter{{Field: "a", Operator: enums.Equal, Value: 1}}}},
+			wantErr: true,
+		},
+		{
+			name:    "missing field",
+			filters: []types.Filter{{Operator: enums.Equal, Value: 1}},
+			wantErr: true,
+		},
+		{
+			name:    "operator field",
+			filters: []types.Filter{{Field: "$where", Operator: enums.Equal, Value: 1}},
+			wantErr: true,
+		},
+		{
+			name:    "between without two bounds",
+			filters: []types.Filter{{Field: "age", Operator: enums.Between, Value: []interface{}{18}}},
+			wantErr: true,
+		},
+		{
+			name: "elemMatch mixing element and field conditions",
+			filters: []types.Filter{
+				{Field: "items", Operator: enums.ElemMatch, Value: []types.Filter{
+					{Field: "sku", Operator: enums.Equal, Value: "x"},
+					{Operator: enums.GreaterThan, Value: 1},
+				}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildMongoFilterTree(tt.filters)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildMongoFilterTree() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildMongoFilterTree() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,632 @@
+package databases
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/educolog9/packages/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/text/collate"
+)
+
+// matchMongoFilter reports whether a document matches a filter built by the MongoDB converters.
+// It evaluates the subset of the query language the converters produce: the logical operators,
+// comparisons, $in, $nin, $regex, $not, $exists, $all, $size, $elemMatch, $text, $geoWithin and $nearSphere.
+// Strings are compared with the collator when given, as MongoDB does with a collation, or byte by byte otherwise.
+func matchMongoFilter(document bson.M, filter bson.M, collator *collate.Collator) (bool, error) {
+	for key, condition := range filter {
+		var matched bool
+		var err error
+
+		switch key {
+		case "$and", "$or", "$nor":
+			list, ok := condition.(bson.A)
+			if !ok {
+				return false, fmt.Errorf("invalid %s condition", key)
+			}
+			matched, err = matchMongoLogical(document, key, list, collator)
+		case "$text":
+			matched = matchMongoText(document, condition)
+		default:
+			values, found := lookupMongoPath(document, key)
+			matched, err = matchMongoFieldCondition(values, found, condition, collator)
+		}
+
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func matchMongoLogical(document bson.M, operator string, list bson.A, collator *collate.Collator) (bool, error) {
+	for _, item := range list {
+		condition, ok := toMongoDocument(item)
+		if !ok {
+			return false, fmt.Errorf("invalid %s condition", operator)
+		}
+
+		matched, err := matchMongoFilter(document, condition, collator)
+		if err != nil {
+			return false, err
+		}
+
+		switch {
+		case operator == "$and" && !matched:
+			return false, nil
+		case operator == "$or" && matched:
+			return true, nil
+		case operator == "$nor" && matched:
+			return false, nil
+		}
+	}
+
+	return operator != "$or", nil
+}
+
+// matchMongoFieldCondition evaluates the operator document of a field against the values found at its path.
+// Every operator must match, and each of them matches when any of the values, or any element of an
+// array value, satisfies it, as MongoDB does.
+func matchMongoFieldCondition(values []interface{}, found bool, condition interface{}, collator *collate.Collator) (bool, error) {
+	operators, ok := toMongoDocument(condition)
+	if !ok || !isMongoOperatorDocument(operators) {
+		return matchMongoEqual(values, found, condition, collator), nil
+	}
+
+	for operator, operand := range operators {
+		var matched bool
+		var err error
+
+		switch operator {
+		case "$eq":
+			matched = matchMongoEqual(values, found, operand, collator)
+		case "$ne":
+			matched = !matchMongoEqual(values, found, operand, collator)
+		case "$gt", "$gte", "$lt", "$lte":
+			matched = matchMongoComparison(values, operator, operand, collator)
+		case "$in", "$nin":
+			list, isList := toMongoList(operand)
+			if !isList {
+				return false, fmt.Errorf("invalid %s condition", operator)
+			}
+			for _, item := range list {
+				if matchMongoEqual(values, found, item, collator) {
+					matched = true
+					break
+				}
+			}
+			if operator == "$nin" {
+				matched = !matched
+			}
+		case "$regex":
+			matched, err = matchMongoRegex(values, operand)
+		case "$not":
+			matched, err = matchMongoRegex(values, operand)
+			matched = !matched
+		case "$exists":
+			exists, _ := operand.(bool)
+			matched = found == exists
+		case "$all":
+			list, isList := toMongoList(operand)
+			if !isList {
+				return false, fmt.Errorf("invalid $all condition")
+			}
+			matched = len(list) > 0
+			for _, item := range list {
+				if !matchMongoEqual(values, found, item, collator) {
+					matched = false
+					break
+				}
+			}
+		case "$size":
+			size, _ := types.FilterValueToInt(operand)
+			for _, value := range values {
+				if list, isList := toMongoList(value); isList && int64(len(list)) == size {
+					matched = true
+				}
+			}
+		case "$elemMatch":
+			matched, err = matchMongoElemMatch(values, operand, collator)
+		case "$geoWithin", "$nearSphere":
+			matched, err = matchMongoGeo(values, operator, operand)
+		default:
+			return false, fmt.Errorf("unsupported operator %s", operator)
+		}
+
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// matchMongoEqual checks if any value, or any element of an array value, equals the operand.
+// A null operand also matches missing fields.
+func matchMongoEqual(values []interface{}, found bool, operand interface{}, collator *collate.Collator) bool {
+	if operand == nil && !found {
+		return true
+	}
+	for _, value := range expandMongoValues(values) {
+		if compareMongoValues(value, operand, collator) == 0 && mongoTypeRank(value) == mongoTypeRank(operand) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchMongoComparison checks if any value of the same type bracket as the operand satisfies the comparison.
+func matchMongoComparison(values []interface{}, operator string, operand interface{}, collator *collate.Collator) bool {
+	for _, value := range expandMongoValues(values) {
+		if mongoTypeRank(value) != mongoTypeRank(operand) {
+			continue
+		}
+
+		c := compareMongoValues(value, operand, collator)
+		switch {
+		case operator == "$gt" && c > 0,
+			operator == "$gte" && c >= 0,
+			operator == "$lt" && c < 0,
+			operator == "$lte" && c <= 0:
+			return true
+		}
+	}
+	return false
+}
+
+func matchMongoRegex(values []interface{}, operand interface{}) (bool, error) {
+	regex, ok := operand.(primitive.Regex)
+	if !ok {
+		return false, fmt.Errorf("invalid $regex condition")
+	}
+
+	pattern := regex.Pattern
+	if strings.Contains(regex.Options, "i") {
+		pattern = "(?i)" + pattern
+	}
+
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, fmt.Errorf("invalid regular expression: %w", err)
+	}
+
+	for _, value := range expandMongoValues(values) {
+		if str, isString := value.(string); isString && compiled.MatchString(str) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// matchMongoElemMatch checks if an element of an array value matches the condition: an operator document
+// for arrays of scalars, or a filter on the fields of the element for arrays of documents.
+func matchMongoElemMatch(values []interface{}, operand interface{}, collator *collate.Collator) (bool, error) {
+	condition, ok := toMongoDocument(operand)
+	if !ok {
+		return false, fmt.Errorf("invalid $elemMatch condition")
+	}
+
+	for _, value := range values {
+		list, isList := toMongoList(value)
+		if !isList {
+			continue
+		}
+
+		for _, element := range list {
+			var matched bool
+			var err error
+
+			if isMongoOperatorDocument(condition) {
+				matched, err = matchMongoFieldCondition([]interface{}{element}, true, condition, collator)
+			} else if document, isDocument := toMongoDocument(element); isDocument {
+				matched, err = matchMongoFilter(document, condition, collator)
+			}
+
+			if err != nil {
+				return false, err
+			}
+			if matched {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// matchMongoText approximates a $text search: the document matches when any word of the search,
+// ignoring case and accents, is a word of any of its string values. Words prefixed with "-" exclude
+// the documents containing them.
+func matchMongoText(document bson.M, condition interface{}) bool {
+	text, _ := toMongoDocument(condition)
+	search, _ := text["$search"].(string)
+
+	words := map[string]bool{}
+	collectMongoWords(document, words)
+
+	matched := false
+	for _, term := range strings.Fields(normalizeMongoText(search)) {
+		if strings.HasPrefix(term, "-") {
+			if words[strings.TrimPrefix(term, "-")] {
+				return false
+			}
+			continue
+		}
+		matched = matched || words[term]
+	}
+
+	return matched
+}
+
+func collectMongoWords(value interface{}, words map[string]bool) {
+	if str, ok := value.(string); ok {
+		for _, word := range strings.FieldsFunc(normalizeMongoText(str), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			words[word] = true
+		}
+		return
+	}
+	if document, ok := toMongoDocument(value); ok {
+		for _, item := range document {
+			collectMongoWords(item, words)
+		}
+		return
+	}
+	if list, ok := toMongoList(value); ok {
+		for _, item := range list {
+			collectMongoWords(item, words)
+		}
+	}
+}
+
+// normalizeMongoText lowercases a text and removes the accents of the letters of accentClasses.
+func normalizeMongoText(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		if base, ok := accentBases[r]; ok {
+			r = base
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// matchMongoGeo evaluates $geoWithin and $nearSphere against point values, given as GeoJSON Points
+// or [longitude, latitude] pairs. Distances are computed on a sphere of radius earthRadiusMeters
+// and polygons are evaluated on the plane of their coordinates.
+func matchMongoGeo(values []interface{}, operator string, operand interface{}) (bool, error) {
+	condition, ok := toMongoDocument(operand)
+	if !ok {
+		return false, fmt.Errorf("invalid %s condition", operator)
+	}
+
+	for _, value := range values {
+		point, err := types.ParseGeoPoint(toGeoValue(value))
+		if err != nil {
+			continue
+		}
+
+		var matched bool
+		switch operator {
+		case "$nearSphere":
+			geometry, _ := toMongoDocument(condition["$geometry"])
+			center, err := types.ParseGeoPoint(toGeoValue(geometry))
+			if err != nil {
+				return false, fmt.Errorf("invalid $nearSphere condition")
+			}
+			distance := geoDistance(center, point)
+			maxDistance, hasMax := condition["$maxDistance"].(float64)
+			minDistance, _ := condition["$minDistance"].(float64)
+			matched = (!hasMax || distance <= maxDistance) && distance >= minDistance
+		case "$geoWithin":
+			if centerSphere, isCircle := toMongoList(condition["$centerSphere"]); isCircle && len(centerSphere) == 2 {
+				center, err := types.ParseGeoPoint(toGeoValue(centerSphere[0]))
+				radians, isRadius := centerSphere[1].(float64)
+				if err != nil || !isRadius {
+					return false, fmt.Errorf("invalid $geoWithin condition")
+				}
+				matched = geoDistance(center, point) <= radians*earthRadiusMeters
+			} else {
+				rings, err := parseGeoWithinRings(condition["$geometry"])
+				if err != nil {
+					return false, fmt.Errorf("invalid $geoWithin condition")
+				}
+				for _, ring := range rings {
+					matched = matched || isPointInRing(point, ring)
+				}
+			}
+		}
+
+		if matched {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// parseGeoWithinRings reads the exterior rings of the GeoJSON Polygon or MultiPolygon of a $geoWithin condition.
+func parseGeoWithinRings(value interface{}) ([][][]float64, error) {
+	value = toGeoValue(value)
+	geometry, ok := value.(map[string]interface{})
+	if !ok || geometry["type"] != "MultiPolygon" {
+		ring, err := types.ParseGeoPolygon(value)
+		if err != nil {
+			return nil, err
+		}
+		return [][][]float64{ring}, nil
+	}
+
+	polygons, _ := geometry["coordinates"].([]interface{})
+	if len(polygons) == 0 {
+		return nil, fmt.Errorf("expected a GeoJSON MultiPolygon")
+	}
+
+	rings := make([][][]float64, len(polygons))
+	for i, polygon := range polygons {
+		ring, err := types.ParseGeoPolygon(map[string]interface{}{"type": "Polygon", "coordinates": polygon})
+		if err != nil {
+			return nil, err
+		}
+		rings[i] = ring
+	}
+	return rings, nil
+}
+
+// geoDistance returns the haversine distance in meters between two points.
+func geoDistance(a, b types.GeoPoint) float64 {
+	lat1, lat2 := a.Latitude*math.Pi/180, b.Latitude*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// isPointInRing checks if a point is inside a closed ring of [longitude, latitude] pairs by ray casting.
+func isPointInRing(point types.GeoPoint, ring [][]float64) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+		if (yi > point.Latitude) != (yj > point.Latitude) && point.Longitude < (xj-xi)*(point.Latitude-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// lookupMongoPath returns the values found at a dotted path of a document. Arrays met before the end of
+// the path are traversed, so "items.price" returns the price of every element of items.
+func lookupMongoPath(document bson.M, path string) ([]interface{}, bool) {
+	current := []interface{}{document}
+
+	for _, part := range strings.Split(path, ".") {
+		var next []interface{}
+		for _, value := range current {
+			if nested, ok := toMongoDocument(value); ok {
+				if item, exists := nested[part]; exists {
+					next = append(next, item)
+				}
+				continue
+			}
+			if list, ok := toMongoList(value); ok {
+				for _, element := range list {
+					if nested, isDocument := toMongoDocument(element); isDocument {
+						if item, exists := nested[part]; exists {
+							next = append(next, item)
+						}
+					}
+				}
+			}
+		}
+		current = next
+	}
+
+	return current, len(current) > 0
+}
+
+// expandMongoValues returns the values together with the elements of the array values.
+func expandMongoValues(values []interface{}) []interface{} {
+	var expanded []interface{}
+	for _, value := range values {
+		if list, ok := toMongoList(value); ok {
+			expanded = append(expanded, list...)
+		}
+		expanded = append(expanded, value)
+	}
+	return expanded
+}
+
+// mongoTypeRank returns the position of the BSON type of a value in the MongoDB sort order.
+// Values of different ranks are never equal nor comparable with $gt, $gte, $lt and $lte.
+func mongoTypeRank(value interface{}) int {
+	value = normalizeMongoValue(value)
+
+	switch value.(type) {
+	case nil:
+		return 1
+	case float64:
+		return 2
+	case string:
+		return 3
+	case bson.M:
+		return 4
+	case bson.A:
+		return 5
+	case primitive.Binary:
+		return 6
+	case primitive.ObjectID:
+		return 7
+	case bool:
+		return 8
+	case primitive.DateTime:
+		return 9
+	case primitive.Timestamp:
+		return 10
+	case primitive.Regex:
+		return 11
+	default:
+		return 12
+	}
+}
+
+// compareMongoValues compares two values following the MongoDB sort order:
+// first by type rank, then by value within the same type. Strings are compared with the collator when given.
+func compareMongoValues(a, b interface{}, collator *collate.Collator) int {
+	a, b = normalizeMongoValue(a), normalizeMongoValue(b)
+
+	rankA, rankB := mongoTypeRank(a), mongoTypeRank(b)
+	if rankA != rankB {
+		return compareOrdered(rankA, rankB)
+	}
+
+	switch x := a.(type) {
+	case float64:
+		return compareOrdered(x, b.(float64))
+	case string:
+		if collator != nil {
+			return collator.CompareString(x, b.(string))
+		}
+		return strings.Compare(x, b.(string))
+	case primitive.ObjectID:
+		return strings.Compare(x.Hex(), b.(primitive.ObjectID).Hex())
+	case bool:
+		y := b.(bool)
+		if x == y {
+			return 0
+		}
+		if !x {
+			return -1
+		}
+		return 1
+	case primitive.DateTime:
+		return compareOrdered(x, b.(primitive.DateTime))
+	case bson.A:
+		y := b.(bson.A)
+		for i := 0; i < len(x) && i < len(y); i++ {
+			if c := compareMongoValues(x[i], y[i], collator); c != 0 {
+				return c
+			}
+		}
+		return compareOrdered(len(x), len(y))
+	default:
+		if reflect.DeepEqual(a, b) {
+			return 0
+		}
+		return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+	}
+}
+
+func compareOrdered[V int | float64 | primitive.DateTime](a, b V) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// normalizeMongoValue converts the equivalent Go types of a value into a single one per BSON type:
+// every number into float64, time.Time into primitive.DateTime, documents into bson.M and lists into bson.A.
+func normalizeMongoValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case time.Time:
+		return primitive.NewDateTimeFromTime(v)
+	}
+
+	if document, ok := toMongoDocument(value); ok {
+		return document
+	}
+	if list, ok := toMongoList(value); ok {
+		return list
+	}
+	return value
+}
+
+func toMongoDocument(value interface{}) (bson.M, bool) {
+	switch v := value.(type) {
+	case bson.M:
+		return v, true
+	case map[string]interface{}:
+		return bson.M(v), true
+	case bson.D:
+		document := bson.M{}
+		for _, e := range v {
+			document[e.Key] = e.Value
+		}
+		return document, true
+	default:
+		return nil, false
+	}
+}
+
+func toMongoList(value interface{}) (bson.A, bool) {
+	switch v := value.(type) {
+	case bson.A:
+		return v, true
+	case []interface{}:
+		return bson.A(v), true
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return nil, false
+	}
+
+	list := make(bson.A, rv.Len())
+	for i := range list {
+		list[i] = rv.Index(i).Interface()
+	}
+	return list, true
+}
+
+// toGeoValue converts documents and lists, at any depth, into the generic JSON types read by types.ParseGeoPoint
+// and types.ParseGeoPolygon.
+func toGeoValue(value interface{}) interface{} {
+	if document, ok := toMongoDocument(value); ok {
+		converted := map[string]interface{}{}
+		for key, item := range document {
+			converted[key] = toGeoValue(item)
+		}
+		return converted
+	}
+	if list, ok := toMongoList(value); ok {
+		converted := make([]interface{}, len(list))
+		for i, item := range list {
+			converted[i] = toGeoValue(item)
+		}
+		return converted
+	}
+	return value
+}
+
+func isMongoOperatorDocument(document bson.M) bool {
+	if len(document) == 0 {
+		return false
+	}
+	for key := range document {
+		if !strings.HasPrefix(key, "$") {
+			return false
+		}
+	}
+	return true
+}
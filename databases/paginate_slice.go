@@ -0,0 +1,151 @@
+package databases
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/educolog9/packages/enums"
+	"github.com/educolog9/packages/types"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// PaginateSlice applies a PaginationConfig to a slice of structs or maps in memory, with the semantics of
+// ConvertPaginationToMongoFilter: the filter it builds is evaluated against every item, and the matching
+// items are sorted, skipped and limited the same way MongoDB would do it.
+// Items are read as MongoDB documents, marshalled with their bson tags, so fields are named as they
+// would be stored in a collection. Strings are compared and sorted with the collation of the config, see
+// BuildMongoCollation, so the results match those of MongoDB.
+// The search text is matched against the words of every string value of the items, since there is no
+// text index to restrict it to. A near filter sorts the items by distance when no sort is requested.
+// Cursor pagination is supported, and the items of a prev page come back in reverse order, as from
+// MongoDB; pass them to NewCursorPage to build the cursors. Projections are not applied.
+// It returns the items of the page and the total number of items matching the filters.
+func PaginateSlice[T any](config *types.PaginationConfig, items []T) ([]T, int64, error) {
+	sortKeys := getSortKeys(config)
+	collator := newMongoCollator(BuildMongoCollation(config))
+
+	filter, err := buildMongoFilter(config, true)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cursorFilter, err := buildCursorFilter(config, sortKeys)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	near, _, err := splitMongoGeoNearFilter(config)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	type entry struct {
+		item     T
+		document bson.M
+	}
+
+	var matches []entry
+	for i, item := range items {
+		document, err := marshalMongoDocument(item)
+		if err != nil {
+			return nil, 0, fmt.Errorf("unable to read item %d: %w", i, err)
+		}
+
+		matched, err := matchMongoFilter(document, filter, collator)
+		if err != nil {
+			return nil, 0, err
+		}
+		if matched {
+			matches = append(matches, entry{item: item, document: document})
+		}
+	}
+
+	total := int64(len(matches))
+
+	if cursorFilter != nil {
+		var page []entry
+		for _, e := range matches {
+			matched, err := matchMongoFilter(e.document, cursorFilter, collator)
+			if err != nil {
+				return nil, 0, err
+			}
+			if matched {
+				page = append(page, e)
+			}
+		}
+		matches = page
+	}
+
+	switch {
+	case len(sortKeys) > 0:
+		sort.SliceStable(matches, func(i, j int) bool {
+			for _, key := range sortKeys {
+				a, _ := lookupMongoPath(matches[i].document, key.Field)
+				b, _ := lookupMongoPath(matches[j].document, key.Field)
+				if c := compareMongoValues(firstMongoValue(a), firstMongoValue(b), collator) * key.Order; c != 0 {
+					return c < 0
+				}
+			}
+			return false
+		})
+	case near != nil:
+		center, err := types.ParseGeoNear(near.Value)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid value for '%s' operator: %w", enums.Near, err)
+		}
+		distance := func(document bson.M) float64 {
+			values, _ := lookupMongoPath(document, near.Field)
+			point, err := types.ParseGeoPoint(toGeoValue(firstMongoValue(values)))
+			if err != nil {
+				return 0
+			}
+			return geoDistance(center.Point, point)
+		}
+		sort.SliceStable(matches, func(i, j int) bool {
+			return distance(matches[i].document) < distance(matches[j].document)
+		})
+	}
+
+	if config.WithLimit {
+		if !config.UsesCursor() {
+			offset := config.Pagination.GetOffset()
+			if offset > int64(len(matches)) {
+				offset = int64(len(matches))
+			}
+			matches = matches[offset:]
+		}
+		if limit := config.Pagination.GetLimit(); limit < int64(len(matches)) {
+			matches = matches[:limit]
+		}
+	}
+
+	page := make([]T, len(matches))
+	for i, e := range matches {
+		page[i] = e.item
+	}
+
+	return page, total, nil
+}
+
+// marshalMongoDocument reads an item as the document MongoDB would store for it.
+func marshalMongoDocument(item interface{}) (bson.M, error) {
+	data, err := bson.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+
+	var document bson.M
+	if err := bson.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	return document, nil
+}
+
+// firstMongoValue returns the first value found at a path, or nil when the path is missing.
+func firstMongoValue(values []interface{}) interface{} {
+	if len(values) == 0 {
+		return nil
+	}
+	return values[0]
+}
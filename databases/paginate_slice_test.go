@@ -0,0 +1,304 @@
+package databases
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/educolog9/packages/enums"
+	"github.com/educolog9/packages/types"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type sliceTestUser struct {
+	ID     string   `bson:"_id"`
+	Name   string   `bson:"name"`
+	Age    *int     `bson:"age"`
+	Active bool     `bson:"active"`
+	Tags   []string `bson:"tags"`
+}
+
+func sliceTestAge(age int) *int {
+	return &age
+}
+
+var sliceTestUsers = []sliceTestUser{
+	{ID: "a", Name: "Ann", Age: sliceTestAge(30), Active: true, Tags: []string{"x", "y"}},
+	{ID: "b", Name: "bob", Age: nil, Active: false, Tags: []string{"x"}},
+	{ID: "c", Name: "Carl", Age: sliceTestAge(25), Active: true, Tags: []string{}},
+	{ID: "d", Name: "dora", Age: sliceTestAge(30), Active: false, Tags: []string{"y"}},
+	{ID: "e", Name: "Eve", Age: nil, Active: true, Tags: []string{"z"}},
+}
+
+var sliceTestCases = []struct {
+	name       string
+	pagination *types.Pagination
+	wantIDs    []string
+	wantTotal  int64
+}{
+	{
+		name:       "ascending sort puts nulls first",
+		pagination: &types.Pagination{Sorts: []types.SortField{{Field: "age", Order: enums.Asc}}},
+		wantIDs:    []string{"b", "e", "c", "a", "d"},
+		wantTotal:  5,
+	},
+	{
+		name:       "descending sort puts nulls last",
+		pagination: &types.Pagination{Sorts: []types.SortField{{Field: "age", Order: enums.Desc}}},
+		wantIDs:    []string{"a", "d", "c", "b", "e"},
+		wantTotal:  5,
+	},
+	{
+		name:       "offset and limit",
+		pagination: &types.Pagination{Offset: 1, Limit: 2, Sorts: []types.SortField{{Field: "age", Order: enums.Asc}}},
+		wantIDs:    []string{"e", "c"},
+		wantTotal:  5,
+	},
+	{
+		name: "conditions on the same field",
+		pagination: &types.Pagination{Filters: []types.Filter{
+			{Field: "age", Operator: enums.GreaterThanOrEqual, Value: 25},
+			{Field: "age", Operator: enums.LessThanOrEqual, Value: 29},
+		}},
+		wantIDs:   []string{"c"},
+		wantTotal: 1,
+	},
+	{
+		name: "filter and sort by name",
+		pagination: &types.Pagination{
+			Sort:    "name",
+			Order:   enums.Desc,
+			Filters: []types.Filter{{Field: "active", Operator: enums.Equal, Value: true}},
+		},
+		wantIDs:   []string{"e", "c", "a"},
+		wantTotal: 3,
+	},
+	{
+		name: "or group",
+		pagination: &types.Pagination{Filters: []types.Filter{
+			{Group: enums.Or, Filters: []types.Filter{
+				{Field: "age", Operator: enums.LessThan, Value: 26},
+				{Field: "name", Operator: enums.Equal, Value: "dora"},
+			}},
+		}},
+		wantIDs:   []string{"c", "d"},
+		wantTotal: 2,
+	},
+	{
+		name: "not group keeps null values",
+		pagination: &types.Pagination{Filters: []types.Filter{
+			{Group: enums.Not, Filters: []types.Filter{
+				{Field: "age", Operator: enums.GreaterThanOrEqual, Value: 30},
+			}},
+		}},
+		wantIDs:   []string{"b", "c", "e"},
+		wantTotal: 3,
+	},
+	{
+		name: "and group inside an or group",
+		pagination: &types.Pagination{Filters: []types.Filter{
+			{Group: enums.Or, Filters: []types.Filter{
+				{Field: "name", Operator: enums.Equal, Value: "bob"},
+				{Group: enums.And, Filters: []types.Filter{
+					{Field: "active", Operator: enums.Equal, Value: true},
+					{Field: "age", Operator: enums.Exists, Value: true},
+					{Field: "age", Operator: enums.NotEqual, Value: nil},
+				}},
+			}},
+		}},
+		wantIDs:   []string{"a", "b", "c"},
+		wantTotal: 3,
+	},
+	{
+		name: "text and array operators",
+		pagination: &types.Pagination{Filters: []types.Filter{
+			{Field: "name", Operator: enums.Contains, Value: "AN"},
+			{Field: "tags", Operator: enums.All, Value: []interface{}{"x", "y"}},
+		}},
+		wantIDs:   []string{"a"},
+		wantTotal: 1,
+	},
+}
+
+func sliceTestIDs(users []sliceTestUser) []string {
+	ids := []string{}
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	return ids
+}
+
+func TestPaginateSlice(t *testing.T) {
+	for _, tt := range sliceTestCases {
+		t.Run(tt.name, func(t *testing.T) {
+			items, total, err := PaginateSlice(types.NewPaginationConfig(tt.pagination), sliceTestUsers)
+			if err != nil {
+				t.Fatalf("PaginateSlice() error = %v", err)
+			}
+			if ids := sliceTestIDs(items); !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("PaginateSlice() = %v, want %v", ids, tt.wantIDs)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("PaginateSlice() total = %d, want %d", total, tt.wantTotal)
+			}
+		})
+	}
+}
+
+// sliceTestFetch returns the items of the page of a config, in the order the database returns them.
+type sliceTestFetch func(t *testing.T, config *types.PaginationConfig) []sliceTestUser
+
+// testCursorWalk follows the next cursors from the first page to the last one and then the prev cursors
+// back to the first one, checking the items of every page.
+func testCursorWalk(t *testing.T, fetch sliceTestFetch) {
+	tests := []struct {
+		name      string
+		sorts     []types.SortField
+		wantPages [][]string
+	}{
+		{
+			name:      "ascending with nulls",
+			sorts:     []types.SortField{{Field: "age", Order: enums.Asc}},
+			wantPages: [][]string{{"b", "e"}, {"c", "a"}, {"d"}},
+		},
+		{
+			name:      "descending with nulls",
+			sorts:     []types.SortField{{Field: "age", Order: enums.Desc}},
+			wantPages: [][]string{{"a", "d"}, {"c", "b"}, {"e"}},
+		},
+		{
+			name:      "two sort fields",
+			sorts:     []types.SortField{{Field: "active", Order: enums.Desc}, {Field: "age", Order: enums.Asc}},
+			wantPages: [][]string{{"e", "c"}, {"a", "b"}, {"d"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := func(next string, prev string) *CursorPage[sliceTestUser] {
+				config := types.NewPaginationConfig(&types.Pagination{Limit: 2, Sorts: tt.sorts, Next: next, Prev: prev})
+				config.WithCursor = true
+
+				cursorPage, err := NewCursorPage(config, fetch(t, config))
+				if err != nil {
+					t.Fatalf("NewCursorPage() error = %v", err)
+				}
+				return cursorPage
+			}
+
+			current := page("", "")
+			for i, want := range tt.wantPages {
+				if ids := sliceTestIDs(current.Items); !reflect.DeepEqual(ids, want) {
+					t.Fatalf("page %d = %v, want %v", i, ids, want)
+				}
+				if i == len(tt.wantPages)-1 {
+					break
+				}
+				if current.Next == "" {
+					t.Fatalf("page %d has no next cursor", i)
+				}
+				current = page(current.Next, "")
+			}
+			if current.Next != "" {
+				t.Errorf("last page has a next cursor")
+			}
+
+			for i := len(tt.wantPages) - 2; i >= 0; i-- {
+				if current.Prev == "" {
+					t.Fatalf("page %d has no prev cursor", i+1)
+				}
+				current = page("", current.Prev)
+				if ids := sliceTestIDs(current.Items); !reflect.DeepEqual(ids, tt.wantPages[i]) {
+					t.Fatalf("page %d walking back = %v, want %v", i, ids, tt.wantPages[i])
+				}
+			}
+
+			if current.Prev != "" {
+				if first := page("", current.Prev); len(first.Items) != 0 {
+					t.Errorf("page before the first one = %v, want none", sliceTestIDs(first.Items))
+				}
+			}
+		})
+	}
+}
+
+func TestPaginateSliceCursorWalk(t *testing.T) {
+	testCursorWalk(t, func(t *testing.T, config *types.PaginationConfig) []sliceTestUser {
+		items, _, err := PaginateSlice(config, sliceTestUsers)
+		if err != nil {
+			t.Fatalf("PaginateSlice() error = %v", err)
+		}
+		return items
+	})
+}
+
+// TestPaginateSliceMatchesMongo runs the PaginateSlice cases against a MongoDB server with the
+// filters of ConvertPaginationToMongoFilter. It is skipped unless MONGODB_TEST_URI is set.
+func TestPaginateSliceMatchesMongo(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGODB_TEST_URI is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("mongo.Connect() error = %v", err)
+	}
+	defer client.Disconnect(ctx)
+
+	collection := client.Database("paginate_slice_test").Collection("users")
+	if err := collection.Drop(ctx); err != nil {
+		t.Fatalf("Drop() error = %v", err)
+	}
+	defer collection.Drop(ctx)
+
+	var documents []interface{}
+	for _, user := range sliceTestUsers {
+		documents = append(documents, user)
+	}
+	if _, err := collection.InsertMany(ctx, documents); err != nil {
+		t.Fatalf("InsertMany() error = %v", err)
+	}
+
+	fetch := func(t *testing.T, config *types.PaginationConfig) []sliceTestUser {
+		filter, findOptions, err := ConvertPaginationToMongoFilter(config)
+		if err != nil {
+			t.Fatalf("ConvertPaginationToMongoFilter() error = %v", err)
+		}
+
+		cursor, err := collection.Find(ctx, filter, findOptions)
+		if err != nil {
+			t.Fatalf("Find() error = %v", err)
+		}
+
+		var users []sliceTestUser
+		if err := cursor.All(ctx, &users); err != nil {
+			t.Fatalf("All() error = %v", err)
+		}
+		return users
+	}
+
+	for _, tt := range sliceTestCases {
+		t.Run(tt.name, func(t *testing.T) {
+			config := types.NewPaginationConfig(tt.pagination)
+
+			want, _, err := PaginateSlice(config, sliceTestUsers)
+			if err != nil {
+				t.Fatalf("PaginateSlice() error = %v", err)
+			}
+			if ids, wantIDs := sliceTestIDs(fetch(t, config)), sliceTestIDs(want); !reflect.DeepEqual(ids, wantIDs) {
+				t.Errorf("Find() = %v, PaginateSlice() = %v", ids, wantIDs)
+			}
+		})
+	}
+
+	t.Run("cursor walk", func(t *testing.T) {
+		testCursorWalk(t, fetch)
+	})
+}
@@ -0,0 +1,201 @@
+package databases
+
+import (
+	"fmt"
+
+	"github.com/educolog9/packages/enums"
+	"github.com/educolog9/packages/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PipelineBuilder assembles the full aggregation pipeline of a PaginationConfig in the correct stage order:
+// $search (Atlas Search) or $geoNear (near filters), $match, stages injected after match (e.g. $lookup), $sort, $skip/$limit,
+// and $project, so documents are always filtered before they are sorted and skipped.
+// Custom stages can be injected at the named points of enums.PipelinePoint.
+type PipelineBuilder struct {
+	config     *types.PaginationConfig
+	stages     map[enums.PipelinePoint]mongo.Pipeline
+	projection bson.D
+}
+
+// NewPipelineBuilder creates a new PipelineBuilder for the given config.
+func NewPipelineBuilder(config *types.PaginationConfig) *PipelineBuilder {
+	return &PipelineBuilder{
+		config: config,
+		stages: map[enums.PipelinePoint]mongo.Pipeline{},
+	}
+}
+
+// Inject adds stages at the given point of the pipeline, after the stages previously injected there.
+func (b *PipelineBuilder) Inject(point enums.PipelinePoint, stages ...bson.D) *PipelineBuilder {
+	b.stages[point] = append(b.stages[point], stages...)
+	return b
+}
+
+// Project sets the projection of the $project stage added after pagination.
+func (b *PipelineBuilder) Project(projection bson.D) *PipelineBuilder {
+	b.projection = projection
+	return b
+}
+
+// Build returns the pipeline of a single page:
+// $search or $geoNear, $match, afterMatch, $sort, afterSort, $skip/$limit, afterPaginate, $project, afterProject.
+func (b *PipelineBuilder) Build() (mongo.Pipeline, error) {
+	sortKeys := getSortKeys(b.config)
+
+	leadingStage, filter, err := buildMongoLeadingStage(b.config)
+	if err != nil {
+		return nil, err
+	}
+
+	cursorFilter, err := buildCursorFilter(b.config, sortKeys)
+	if err != nil {
+		return nil, err
+	}
+	if cursorFilter != nil {
+		appendMongoAnd(filter, cursorFilter)
+	}
+
+	var pipeline mongo.Pipeline
+	if leadingStage != nil {
+		pipeline = append(pipeline, leadingStage)
+	}
+	if len(filter) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: filter}})
+	}
+
+	pipeline = append(pipeline, b.stages[enums.AfterMatch]...)
+
+	pageStages, err := b.buildPageStages(sortKeys)
+	if err != nil {
+		return nil, err
+	}
+	pipeline = append(pipeline, pageStages...)
+
+	return pipeline, nil
+}
+
+// BuildFacet returns a pipeline returning the page and the total count in a single document:
+// $search or $geoNear, $match, afterMatch, and a $facet whose "items" sub-pipeline holds the cursor range
+// and the rest of the stages of Build, and whose "total" sub-pipeline counts every document matching the filters.
+// Each facet requested by the pagination adds a sub-pipeline named after it, counting the same documents as "total".
+// Use DecodeMongoFacetResult to read its result.
+func (b *PipelineBuilder) BuildFacet() (mongo.Pipeline, error) {
+	sortKeys := getSortKeys(b.config)
+
+	leadingStage, filter, err := buildMongoLeadingStage(b.config)
+	if err != nil {
+		return nil, err
+	}
+
+	var pipeline mongo.Pipeline
+	if leadingStage != nil {
+		pipeline = append(pipeline, leadingStage)
+	}
+	if len(filter) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: filter}})
+	}
+
+	pipeline = append(pipeline, b.stages[enums.AfterMatch]...)
+
+	cursorFilter, err := buildCursorFilter(b.config, sortKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	items := mongo.Pipeline{}
+	if cursorFilter != nil {
+		items = append(items, bson.D{{Key: "$match", Value: cursorFilter}})
+	}
+	pageStages, err := b.buildPageStages(sortKeys)
+	if err != nil {
+		return nil, err
+	}
+	items = append(items, pageStages...)
+
+	facets, err := buildMongoFacets(b.config)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline = append(pipeline, bson.D{{Key: "$facet", Value: append(bson.D{
+		{Key: "items", Value: items},
+		{Key: "total", Value: bson.A{bson.D{{Key: "$count", Value: "count"}}}},
+	}, facets...)}})
+
+	return pipeline, nil
+}
+
+// buildMongoLeadingStage returns the stage that must open the pipeline, if any: the $search stage when
+// the config uses Atlas Search, or the $geoNear stage of a near filter, which takes the rest of the
+// filters as its query. It also returns the filter still to be applied in a $match right after that
+// stage, without the cursor range.
+func buildMongoLeadingStage(config *types.PaginationConfig) (bson.D, bson.M, error) {
+	searchStage, matchConfig, err := buildMongoSearchStage(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	near, matchConfig, err := splitMongoGeoNearFilter(matchConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	filter, err := buildMongoFilter(matchConfig, !config.WithAtlasSearch)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if near == nil {
+		return searchStage, filter, nil
+	}
+
+	if searchStage != nil {
+		return nil, nil, fmt.Errorf("'near' filters cannot be combined with Atlas Search")
+	}
+	if _, ok := filter["$text"]; ok {
+		return nil, nil, fmt.Errorf("'near' filters cannot be combined with text search")
+	}
+
+	geoNearStage, err := buildMongoGeoNearStage(*near, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return geoNearStage, bson.M{}, nil
+}
+
+// buildPageStages returns the stages from $sort to afterProject.
+// The fields requested by the pagination are projected after the projection set with Project.
+func (b *PipelineBuilder) buildPageStages(sortKeys []sortKey) (mongo.Pipeline, error) {
+	var pipeline mongo.Pipeline
+
+	if len(sortKeys) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$sort", Value: buildMongoSort(sortKeys)}})
+	}
+	pipeline = append(pipeline, b.stages[enums.AfterSort]...)
+
+	if b.config.WithLimit {
+		if !b.config.UsesCursor() {
+			pipeline = append(pipeline, bson.D{{Key: "$skip", Value: b.config.Pagination.GetOffset()}})
+		}
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: b.config.Pagination.GetLimit()}})
+	}
+	pipeline = append(pipeline, b.stages[enums.AfterPaginate]...)
+
+	if len(b.projection) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$project", Value: b.projection}})
+	}
+
+	projection, err := buildMongoProjection(b.config)
+	if err != nil {
+		return nil, err
+	}
+	if len(projection) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$project", Value: projection}})
+	}
+	pipeline = append(pipeline, b.stages[enums.AfterProject]...)
+
+	return pipeline, nil
+}
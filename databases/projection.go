@@ -0,0 +1,111 @@
+package databases
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/educolog9/packages/types"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// buildMongoProjection converts the fields requested by the pagination into a MongoDB projection:
+// 1 for every field of Pagination.Fields, or 0 for every field of Pagination.ExcludeFields.
+// The sort fields and _id are always returned, so NewCursorPage can build the cursors from the returned
+// documents: when fields are included, the sort fields are included too, and when fields are excluded,
+// the exclusions of _id, of a sort field, or of a parent or child of one are ignored.
+// Requested fields colliding with each other, such as "address" and "address.city", are rejected, as
+// MongoDB does. It returns an empty projection when the pagination requests every field.
+func buildMongoProjection(config *types.PaginationConfig) (bson.D, error) {
+	pagination := config.Pagination
+
+	if len(pagination.GetFields()) > 0 && len(pagination.GetExcludeFields()) > 0 {
+		return nil, fmt.Errorf("fields and excludeFields cannot be combined")
+	}
+
+	sortKeys := getSortKeys(config)
+	projection := bson.D{}
+	seen := map[string]bool{}
+
+	add := func(field string, value int) error {
+		if field == "" || strings.HasPrefix(field, "$") {
+			return fmt.Errorf("invalid projection field '%s'", field)
+		}
+		if seen[field] {
+			return nil
+		}
+		for _, e := range projection {
+			if isParentPath(e.Key, field) || isParentPath(field, e.Key) {
+				return fmt.Errorf("projection fields '%s' and '%s' collide", e.Key, field)
+			}
+		}
+		seen[field] = true
+		projection = append(projection, bson.E{Key: field, Value: value})
+		return nil
+	}
+
+	for _, field := range pagination.GetExcludeFields() {
+		if err := add(field, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(pagination.GetFields()) == 0 {
+		kept := bson.D{}
+		for _, e := range projection {
+			if !isReturnedPath(e.Key, sortKeys) {
+				kept = append(kept, e)
+			}
+		}
+		return kept, nil
+	}
+
+	for _, field := range pagination.GetFields() {
+		if err := add(field, 1); err != nil {
+			return nil, err
+		}
+	}
+	for _, key := range sortKeys {
+		if isProjectedPath(projection, key.Field) {
+			continue
+		}
+		kept := bson.D{}
+		for _, e := range projection {
+			if !isParentPath(key.Field, e.Key) {
+				kept = append(kept, e)
+			}
+		}
+		projection = append(kept, bson.E{Key: key.Field, Value: 1})
+	}
+
+	return projection, nil
+}
+
+// isProjectedPath checks if a path or one of its parents is already in the projection,
+// since MongoDB rejects projections with colliding paths such as "address" and "address.city".
+func isProjectedPath(projection bson.D, path string) bool {
+	for _, e := range projection {
+		if e.Key == path || isParentPath(e.Key, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// isReturnedPath checks if a path is _id, a sort field, or a parent or child of one of them,
+// which must not be excluded from the returned documents.
+func isReturnedPath(path string, sortKeys []sortKey) bool {
+	if path == "_id" || isParentPath("_id", path) {
+		return true
+	}
+	for _, key := range sortKeys {
+		if path == key.Field || isParentPath(path, key.Field) || isParentPath(key.Field, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// isParentPath checks if a document path is a parent of another, e.g. "address" of "address.city".
+func isParentPath(parent string, path string) bool {
+	return strings.HasPrefix(path, parent+".")
+}
@@ -0,0 +1,89 @@
+package databases
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/educolog9/packages/types"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// accentClasses maps each Spanish base letter to the letters it matches when accents are ignored.
+var accentClasses = map[rune]string{
+	'a': "aáàâäã",
+	'e': "eéèêë",
+	'i': "iíìîï",
+	'o': "oóòôöõ",
+	'u': "uúùûü",
+	'n': "nñ",
+	'c': "cç",
+}
+
+// accentBases maps every letter of accentClasses to its base letter.
+var accentBases = map[rune]rune{}
+
+func init() {
+	for base, class := range accentClasses {
+		for _, r := range class {
+			accentBases[r] = base
+		}
+	}
+}
+
+// buildMongoTextRegex builds the regular expression of a text filter (like, notLike, startsWith,
+// endsWith and contains) from the filter value and its match options.
+// The value is escaped and matched as a literal unless the options enable raw mode and allowRaw is set.
+// The prefix and suffix are appended around the literal, e.g. "^" for startsWith.
+// The filter must have been checked with types.Filter.Validate, which validates raw expressions.
+func buildMongoTextRegex(f types.Filter, allowRaw bool, caseInsensitive bool, prefix string, suffix string) primitive.Regex {
+	value, _ := f.Value.(string)
+
+	options := f.GetMatch()
+	caseInsensitive = caseInsensitive || options.CaseInsensitive
+
+	var pattern string
+	if options.Raw && allowRaw {
+		pattern = value
+	} else {
+		pattern = prefix + buildLiteralRegex(value, caseInsensitive, options.AccentInsensitive) + suffix
+	}
+
+	regexOptions := ""
+	if caseInsensitive {
+		regexOptions = "i"
+	}
+
+	return primitive.Regex{Pattern: pattern, Options: regexOptions}
+}
+
+// buildLiteralRegex escapes a value so it is matched as a literal substring.
+// When accents are ignored, every Spanish vowel, "n" and "c" is replaced by a character class
+// with its accented variants, in both cases when case is ignored too.
+func buildLiteralRegex(value string, caseInsensitive bool, accentInsensitive bool) string {
+	if !accentInsensitive {
+		return regexp.QuoteMeta(value)
+	}
+
+	var b strings.Builder
+	for _, r := range value {
+		lower := unicode.ToLower(r)
+		base, ok := accentBases[lower]
+		if !ok {
+			b.WriteString(regexp.QuoteMeta(string(r)))
+			continue
+		}
+
+		class := accentClasses[base]
+		switch {
+		case caseInsensitive:
+			class += strings.ToUpper(class)
+		case unicode.IsUpper(r):
+			class = strings.ToUpper(class)
+		}
+
+		b.WriteString("[" + class + "]")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,356 @@
+package databases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	customerrors "github.com/educolog9/packages/errors/custom_errors"
+	"github.com/educolog9/packages/types"
+	"github.com/opentracing/opentracing-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Repository implements the common CRUD operations of a MongoDB collection whose documents decode into T.
+// Every method starts an opentracing span from the request context, so driver calls are traced and
+// cancelled with the request, and returns the custom_errors types: BadImplementationError when the pagination
+// cannot be translated, ForbiddenError when it reaches outside the organization of the tenant, NotFoundError
+// when no document has the given ID, and the driver errors translated by TranslateMongoError, such as
+// DuplicateKeyError on unique index violations.
+// When the context holds a Tenant, as set by TenancyMiddleware, every call is restricted to its organization:
+// reads, updates and deletes only reach documents of the organization, and inserted or replaced documents
+// must belong to it.
+// IDs are given as they are stored, except for ObjectID hex strings, such as the ones set by
+// ParseMongoIDMiddleware, which are converted to ObjectIDs.
+type Repository[T any] struct {
+	Collection *mongo.Collection
+}
+
+// NewRepository creates a new Repository for the given collection.
+func NewRepository[T any](collection *mongo.Collection) *Repository[T] {
+	return &Repository[T]{
+		Collection: collection,
+	}
+}
+
+// FindByID returns the document with the given ID.
+func (r *Repository[T]) FindByID(ctx context.Context, id interface{}) (*T, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Repository.FindByID")
+	defer span.Finish()
+
+	filter, err := buildTenantDocumentFilter(types.TenantFromContext(ctx), id)
+	if err != nil {
+		return nil, newRepositoryError(err)
+	}
+
+	var item T
+	if err := r.Collection.FindOne(ctx, filter).Decode(&item); err != nil {
+		return nil, TranslateMongoError(err)
+	}
+	return &item, nil
+}
+
+// FindPage returns the page of documents requested by the config together with the total count and the
+// requested facets. The page runs the pipeline of NewPipelineBuilder, so its sort and limit can use an
+// index, and the count and the facets run as separate queries with the same filters.
+// The queries run with the collation of the config; see BuildMongoCollation.
+func (r *Repository[T]) FindPage(ctx context.Context, config *types.PaginationConfig) (*types.PaginatedResponse[T], error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Repository.FindPage")
+	defer span.Finish()
+
+	config = scopeRepositoryConfig(ctx, config)
+
+	pipeline, err := NewPipelineBuilder(config).Build()
+	if err != nil {
+		return nil, newRepositoryError(err)
+	}
+
+	cursor, err := r.Collection.Aggregate(ctx, pipeline, NewMongoAggregateOptions(config))
+	if err != nil {
+		return nil, TranslateMongoError(err)
+	}
+
+	var items []T
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, TranslateMongoError(err)
+	}
+
+	total, err := r.Count(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	facets, err := r.findFacets(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := buildMongoPageResponse(items, total, facets, config)
+	if err != nil {
+		return nil, newRepositoryError(err)
+	}
+
+	return response, nil
+}
+
+// Count returns the number of documents matching the filters and search of the config.
+func (r *Repository[T]) Count(ctx context.Context, config *types.PaginationConfig) (int64, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Repository.Count")
+	defer span.Finish()
+
+	config = scopeRepositoryConfig(ctx, config)
+
+	leadingStage, filter, err := buildMongoLeadingStage(config)
+	if err != nil {
+		return 0, newRepositoryError(err)
+	}
+
+	if leadingStage == nil {
+		countOptions := options.Count()
+		if collation := BuildMongoCollation(config); collation != nil {
+			countOptions.SetCollation(collation)
+		}
+		count, err := r.Collection.CountDocuments(ctx, filter, countOptions)
+		if err != nil {
+			return 0, TranslateMongoError(err)
+		}
+		return count, nil
+	}
+
+	// $search and $geoNear must open the pipeline, so they cannot be counted with CountDocuments.
+	pipeline := mongo.Pipeline{leadingStage}
+	if len(filter) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: filter}})
+	}
+	pipeline = append(pipeline, bson.D{{Key: "$count", Value: "count"}})
+
+	cursor, err := r.Collection.Aggregate(ctx, pipeline, NewMongoAggregateOptions(config))
+	if err != nil {
+		return 0, TranslateMongoError(err)
+	}
+	defer cursor.Close(ctx)
+
+	var result struct {
+		Count int64 `bson:"count"`
+	}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&result); err != nil {
+			return 0, TranslateMongoError(err)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return 0, TranslateMongoError(err)
+	}
+
+	return result.Count, nil
+}
+
+// Insert inserts a new document and returns its ID.
+func (r *Repository[T]) Insert(ctx context.Context, item *T) (interface{}, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Repository.Insert")
+	defer span.Finish()
+
+	if err := checkTenantDocument(types.TenantFromContext(ctx), item); err != nil {
+		return nil, newRepositoryError(err)
+	}
+
+	result, err := r.Collection.InsertOne(ctx, item)
+	if err != nil {
+		return nil, TranslateMongoError(err)
+	}
+	return result.InsertedID, nil
+}
+
+// Update replaces the document with the given ID.
+func (r *Repository[T]) Update(ctx context.Context, id interface{}, item *T) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Repository.Update")
+	defer span.Finish()
+
+	filter, err := buildTenantWriteFilter(ctx, id, item)
+	if err != nil {
+		return newRepositoryError(err)
+	}
+
+	result, err := r.Collection.ReplaceOne(ctx, filter, item)
+	if err != nil {
+		return TranslateMongoError(err)
+	}
+	if result.MatchedCount == 0 {
+		return customerrors.NewNotFoundError("", nil)
+	}
+	return nil
+}
+
+// Patch sets the given fields of the document with the given ID, leaving the other fields untouched.
+// Fields setting the organization field of the tenant, one of its subfields or a parent of it are rejected.
+func (r *Repository[T]) Patch(ctx context.Context, id interface{}, fields bson.M) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Repository.Patch")
+	defer span.Finish()
+
+	if len(fields) == 0 {
+		return customerrors.NewBadImplementationError("", fmt.Errorf("no fields to patch"))
+	}
+	if _, ok := fields["_id"]; ok {
+		return customerrors.NewBadImplementationError("", fmt.Errorf("the _id field cannot be patched"))
+	}
+
+	tenant := types.TenantFromContext(ctx)
+	if tenant != nil && !tenant.IsBypass() {
+		field := tenant.GetField()
+		for key := range fields {
+			if key == field || strings.HasPrefix(field, key+".") || strings.HasPrefix(key, field+".") {
+				return newRepositoryError(fmt.Errorf("%w: the %s field cannot be patched", ErrTenancyViolation, key))
+			}
+		}
+	}
+
+	filter, err := buildTenantDocumentFilter(tenant, id)
+	if err != nil {
+		return newRepositoryError(err)
+	}
+
+	result, err := r.Collection.UpdateOne(ctx, filter, bson.M{"$set": fields})
+	if err != nil {
+		return TranslateMongoError(err)
+	}
+	if result.MatchedCount == 0 {
+		return customerrors.NewNotFoundError("", nil)
+	}
+	return nil
+}
+
+// Delete deletes the document with the given ID.
+func (r *Repository[T]) Delete(ctx context.Context, id interface{}) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Repository.Delete")
+	defer span.Finish()
+
+	filter, err := buildTenantDocumentFilter(types.TenantFromContext(ctx), id)
+	if err != nil {
+		return newRepositoryError(err)
+	}
+
+	result, err := r.Collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return TranslateMongoError(err)
+	}
+	if result.DeletedCount == 0 {
+		return customerrors.NewNotFoundError("", nil)
+	}
+	return nil
+}
+
+// Upsert replaces the document with the given ID, inserting it when it does not exist.
+// It reports whether the document was inserted. When the ID belongs to a document of another organization,
+// it returns a NotFoundError, as the other methods do.
+func (r *Repository[T]) Upsert(ctx context.Context, id interface{}, item *T) (bool, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Repository.Upsert")
+	defer span.Finish()
+
+	filter, err := buildTenantWriteFilter(ctx, id, item)
+	if err != nil {
+		return false, newRepositoryError(err)
+	}
+
+	opts := options.Replace().SetUpsert(true)
+
+	result, err := r.Collection.ReplaceOne(ctx, filter, item, opts)
+	if err != nil {
+		// The ID is taken by a document outside the tenant scope, which the upsert tried to insert again.
+		translated := TranslateMongoError(err)
+		if duplicate, ok := translated.(*customerrors.DuplicateKeyError); ok && duplicate.Index == "_id_" {
+			return false, customerrors.NewNotFoundError("", err)
+		}
+		return false, translated
+	}
+	return result.UpsertedCount > 0, nil
+}
+
+// findFacets counts the facets requested by the config in a single aggregation, returning nil when none are requested.
+func (r *Repository[T]) findFacets(ctx context.Context, config *types.PaginationConfig) (map[string][]types.FacetBucket, error) {
+	if len(config.Pagination.GetFacets()) == 0 {
+		return nil, nil
+	}
+
+	leadingStage, filter, err := buildMongoLeadingStage(config)
+	if err != nil {
+		return nil, newRepositoryError(err)
+	}
+	facets, err := buildMongoFacets(config)
+	if err != nil {
+		return nil, newRepositoryError(err)
+	}
+
+	var pipeline mongo.Pipeline
+	if leadingStage != nil {
+		pipeline = append(pipeline, leadingStage)
+	}
+	if len(filter) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: filter}})
+	}
+	pipeline = append(pipeline, bson.D{{Key: "$facet", Value: facets}})
+
+	cursor, err := r.Collection.Aggregate(ctx, pipeline, NewMongoAggregateOptions(config))
+	if err != nil {
+		return nil, TranslateMongoError(err)
+	}
+	defer cursor.Close(ctx)
+
+	var result map[string][]types.FacetBucket
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&result); err != nil {
+			return nil, TranslateMongoError(err)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, TranslateMongoError(err)
+	}
+
+	return result, nil
+}
+
+// newRepositoryError returns the custom error of a query or a document the repository cannot run:
+// a ForbiddenError for tenancy violations, and a BadImplementationError otherwise.
+func newRepositoryError(err error) customerrors.BaseErrorInterface {
+	if errors.Is(err, ErrTenancyViolation) {
+		return customerrors.NewForbiddenError("", err)
+	}
+	return customerrors.NewBadImplementationError("", err)
+}
+
+// scopeRepositoryConfig returns a copy of the config scoped to the tenant of the context,
+// unless the config already has a tenant.
+func scopeRepositoryConfig(ctx context.Context, config *types.PaginationConfig) *types.PaginationConfig {
+	if config.Tenant != nil {
+		return config
+	}
+
+	scoped := *config
+	scoped.Tenant = types.TenantFromContext(ctx)
+	return &scoped
+}
+
+// buildTenantWriteFilter returns the filter of a replacement of the document with the given ID,
+// checking the new document stays in the organization of the tenant of the context.
+func buildTenantWriteFilter(ctx context.Context, id interface{}, item interface{}) (bson.M, error) {
+	tenant := types.TenantFromContext(ctx)
+
+	if err := checkTenantDocument(tenant, item); err != nil {
+		return nil, err
+	}
+
+	return buildTenantDocumentFilter(tenant, id)
+}
+
+// resolveRepositoryID converts ObjectID hex strings into ObjectIDs and returns any other ID as it is.
+func resolveRepositoryID(id interface{}) interface{} {
+	if str, ok := id.(string); ok {
+		if objectID, err := primitive.ObjectIDFromHex(str); err == nil {
+			return objectID
+		}
+	}
+	return id
+}
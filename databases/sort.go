@@ -0,0 +1,64 @@
+package databases
+
+import (
+	"github.com/educolog9/packages/enums"
+	"github.com/educolog9/packages/types"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// sortKey is a single field of a sort specification.
+// Order is 1 for ascending and -1 for descending, as MongoDB expects it.
+type sortKey struct {
+	Field string
+	Order int
+}
+
+// getSortKeys returns the ordered sort specification requested by the pagination.
+// Repeated fields keep their first occurrence. Whenever the query is sorted or uses cursor
+// pagination, _id is appended as a tiebreaker so page boundaries are deterministic, and the
+// orders are reversed when paginating backwards with a prev cursor.
+func getSortKeys(config *types.PaginationConfig) []sortKey {
+	pagination := config.Pagination
+
+	var keys []sortKey
+	seen := map[string]bool{}
+	for _, sort := range pagination.GetSorts() {
+		if sort.Field == "" || seen[sort.Field] {
+			continue
+		}
+		seen[sort.Field] = true
+		keys = append(keys, sortKey{Field: sort.Field, Order: sortOrderToInt(sort.GetOrder())})
+	}
+
+	if len(keys) == 0 && !config.UsesCursor() {
+		return keys
+	}
+
+	if !seen["_id"] {
+		keys = append(keys, sortKey{Field: "_id", Order: 1})
+	}
+
+	if isBackward(pagination) {
+		for i := range keys {
+			keys[i].Order = -keys[i].Order
+		}
+	}
+
+	return keys
+}
+
+// buildMongoSort converts a sort specification into an ordered bson.D.
+func buildMongoSort(keys []sortKey) bson.D {
+	sort := bson.D{}
+	for _, key := range keys {
+		sort = append(sort, bson.E{Key: key.Field, Value: key.Order})
+	}
+	return sort
+}
+
+func sortOrderToInt(order enums.SortOrder) int {
+	if order == enums.Desc {
+		return -1
+	}
+	return 1
+}
@@ -0,0 +1,279 @@
+package databases
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/educolog9/packages/enums"
+	"github.com/educolog9/packages/types"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// sqlBuilder collects the arguments of a parameterized SQL clause and numbers their placeholders.
+type sqlBuilder struct {
+	args []interface{}
+}
+
+// param adds an argument and returns its PostgreSQL placeholder, e.g. "$3".
+func (b *sqlBuilder) param(value interface{}) string {
+	b.args = append(b.args, sqlArgValue(value))
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+// params adds a list of arguments and returns their placeholders separated by commas.
+func (b *sqlBuilder) params(values []interface{}) string {
+	placeholders := make([]string, len(values))
+	for i, value := range values {
+		placeholders[i] = b.param(value)
+	}
+	return strings.Join(placeholders, ", ")
+}
+
+// buildSQLFilterTree translates a list of filters into a condition joined with AND.
+// It returns an empty string when there are no filters.
+func (b *sqlBuilder) buildSQLFilterTree(filters []types.Filter) (string, error) {
+	var conditions []string
+
+	for _, f := range filters {
+		condition, err := b.buildSQLCondition(f)
+		if err != nil {
+			return "", err
+		}
+		conditions = append(conditions, condition)
+	}
+
+	return strings.Join(conditions, " AND "), nil
+}
+
+// buildSQLCondition translates a single filter, either a field condition or a group.
+func (b *sqlBuilder) buildSQLCondition(f types.Filter) (string, error) {
+	if f.IsGroup() {
+		return b.buildSQLGroupCondition(f)
+	}
+
+	if f.Field == "" {
+		return "", fmt.Errorf("missing field for '%s' operator", f.Operator)
+	}
+
+	column, err := quoteSQLIdentifier(f.Field)
+	if err != nil {
+		return "", err
+	}
+
+	return b.buildSQLOperatorCondition(column, f)
+}
+
+// buildSQLGroupCondition translates a filter group into AND, OR or NOT.
+func (b *sqlBuilder) buildSQLGroupCondition(group types.Filter) (string, error) {
+	if len(group.Filters) == 0 {
+		return "", fmt.Errorf("empty '%s' filter group", group.Group)
+	}
+
+	switch group.Group {
+	case enums.And, enums.Not:
+		condition, err := b.buildSQLFilterTree(group.Filters)
+		if err != nil {
+			return "", err
+		}
+		if group.Group == enums.Not {
+			// A condition on a NULL column is unknown, which NOT keeps unknown; it is read as a non-match
+			// so NULL columns are kept, as $nor keeps documents with missing fields.
+			return "NOT COALESCE((" + condition + "), false)", nil
+		}
+		return "(" + condition + ")", nil
+	case enums.Or:
+		var conditions []string
+		for _, child := range group.Filters {
+			condition, err := b.buildSQLCondition(child)
+			if err != nil {
+				return "", err
+			}
+			conditions = append(conditions, condition)
+		}
+		return "(" + strings.Join(conditions, " OR ") + ")", nil
+	default:
+		return "", fmt.Errorf("unsupported filter group %s", group.Group)
+	}
+}
+
+// buildSQLOperatorCondition translates a single field filter into a condition on the quoted column.
+// The operators keep the semantics of their MongoDB translation: notEqual, notIn and notLike also
+// match NULL columns, as their MongoDB counterparts match missing fields.
+func (b *sqlBuilder) buildSQLOperatorCondition(column string, f types.Filter) (string, error) {
+	switch f.Operator {
+	case enums.Equal, enums.NotEqual, enums.GreaterThan, enums.GreaterThanOrEqual, enums.LessThan, enums.LessThanOrEqual:
+		value, err := resolveSQLFilterValue(f)
+		if err != nil {
+			return "", err
+		}
+		if value == nil {
+			switch f.Operator {
+			case enums.Equal:
+				return column + " IS NULL", nil
+			case enums.NotEqual:
+				return column + " IS NOT NULL", nil
+			default:
+				return "", types.InvalidFilterValueError(f.Operator, "a non-null value")
+			}
+		}
+		if f.Operator == enums.NotEqual {
+			return fmt.Sprintf("(%s <> %s OR %s IS NULL)", column, b.param(value), column), nil
+		}
+		return fmt.Sprintf("%s %s %s", column, sqlComparisonOperators[f.Operator], b.param(value)), nil
+	case enums.In, enums.NotIn:
+		values, err := f.ResolveInValues()
+		if err != nil {
+			return "", err
+		}
+		if f.Operator == enums.In {
+			if len(values) == 0 {
+				return "FALSE", nil
+			}
+			return fmt.Sprintf("%s IN (%s)", column, b.params(values)), nil
+		}
+		if len(values) == 0 {
+			return "TRUE", nil
+		}
+		return fmt.Sprintf("(%s NOT IN (%s) OR %s IS NULL)", column, b.params(values), column), nil
+	case enums.Like:
+		return b.buildSQLTextCondition(column, f, true, false, "%", "%")
+	case enums.NotLike:
+		condition, err := b.buildSQLTextCondition(column, f, true, false, "%", "%")
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("(NOT %s OR %s IS NULL)", condition, column), nil
+	case enums.StartsWith:
+		return b.buildSQLTextCondition(column, f, false, false, "", "%")
+	case enums.EndsWith:
+		return b.buildSQLTextCondition(column, f, false, false, "%", "")
+	case enums.Contains:
+		return b.buildSQLTextCondition(column, f, false, true, "%", "%")
+	case enums.Exists:
+		exists, err := types.FilterValueToBool(f.Value)
+		if err != nil {
+			return "", types.InvalidFilterValueError(f.Operator, "a boolean")
+		}
+		if exists {
+			return column + " IS NOT NULL", nil
+		}
+		return column + " IS NULL", nil
+	case enums.Between:
+		bounds, err := f.ResolveInValues()
+		if err != nil {
+			return "", err
+		}
+		if len(bounds) != 2 {
+			return "", types.InvalidFilterValueError(f.Operator, "a list with a lower and an upper bound")
+		}
+		return fmt.Sprintf("%s BETWEEN %s AND %s", column, b.param(bounds[0]), b.param(bounds[1])), nil
+	case enums.All:
+		values, err := f.ResolveInValues()
+		if err != nil {
+			return "", err
+		}
+		if len(values) == 0 {
+			return "", types.InvalidFilterValueError(f.Operator, "a non-empty list")
+		}
+		return fmt.Sprintf("%s @> ARRAY[%s]", column, b.params(values)), nil
+	case enums.Size:
+		size, err := types.FilterValueToInt(f.Value)
+		if err != nil || size < 0 {
+			return "", types.InvalidFilterValueError(f.Operator, "a non-negative integer")
+		}
+		return fmt.Sprintf("COALESCE(cardinality(%s), 0) = %s", column, b.param(size)), nil
+	case enums.ElemMatch, enums.Near, enums.WithinRadius, enums.WithinBox, enums.WithinPolygon:
+		return "", fmt.Errorf("operator %s is not supported by the SQL converter", f.Operator)
+	default:
+		return "", fmt.Errorf("unsupported operator %s", f.Operator)
+	}
+}
+
+// buildSQLTextCondition translates a text filter into LIKE, or ILIKE when case is ignored, with the value
+// escaped so it is matched as a literal between the given prefix and suffix wildcards.
+// When the match options ignore accents both sides are wrapped in unaccent, which requires the PostgreSQL
+// unaccent extension. Raw mode, only allowed when allowRaw is set, uses the ~ and ~* regex operators
+// with the same validation as the MongoDB converters.
+func (b *sqlBuilder) buildSQLTextCondition(column string, f types.Filter, allowRaw bool, caseInsensitive bool, prefix string, suffix string) (string, error) {
+	value, err := f.TextValue()
+	if err != nil {
+		return "", err
+	}
+
+	options := f.GetMatch()
+	caseInsensitive = caseInsensitive || options.CaseInsensitive
+
+	if options.Raw && allowRaw {
+		if err := options.ValidateRegex(value); err != nil {
+			return "", fmt.Errorf("invalid value for '%s' operator: %w", f.Operator, err)
+		}
+		operator := "~"
+		if caseInsensitive {
+			operator = "~*"
+		}
+		return fmt.Sprintf("%s %s %s", column, operator, b.param(value)), nil
+	}
+
+	operator := "LIKE"
+	if caseInsensitive {
+		operator = "ILIKE"
+	}
+
+	pattern := b.param(prefix + escapeSQLLike(value) + suffix)
+	if options.AccentInsensitive {
+		column = "unaccent(" + column + ")"
+		pattern = "unaccent(" + pattern + ")"
+	}
+
+	return fmt.Sprintf("%s %s %s ESCAPE '\\'", column, operator, pattern), nil
+}
+
+// resolveSQLFilterValue returns the value of a scalar filter coerced to the filter type.
+// Unlike types.Filter.ResolveValue, untyped hex strings are kept as strings.
+func resolveSQLFilterValue(f types.Filter) (interface{}, error) {
+	if f.Type == "" {
+		return f.Value, nil
+	}
+	return f.ResolveValue()
+}
+
+// sqlArgValue converts the BSON types produced by value coercion and cursor decoding
+// into values database/sql drivers accept.
+func sqlArgValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case primitive.DateTime:
+		return v.Time().UTC()
+	case primitive.ObjectID:
+		return v.Hex()
+	default:
+		return value
+	}
+}
+
+// quoteSQLIdentifier quotes a column name, or a dotted table.column name, as a PostgreSQL identifier.
+// Double quotes inside the name are doubled, so any name is safe to embed in a query.
+func quoteSQLIdentifier(name string) (string, error) {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		if part == "" {
+			return "", fmt.Errorf("invalid field %s", name)
+		}
+		parts[i] = `"` + strings.ReplaceAll(part, `"`, `""`) + `"`
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// escapeSQLLike escapes the LIKE wildcards of a value so it is matched as a literal.
+func escapeSQLLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+// sqlComparisonOperators maps the comparison operators to their SQL operators.
+var sqlComparisonOperators = map[enums.Operator]string{
+	enums.Equal:              "=",
+	enums.GreaterThan:        ">",
+	enums.GreaterThanOrEqual: ">=",
+	enums.LessThan:           "<",
+	enums.LessThanOrEqual:    "<=",
+}
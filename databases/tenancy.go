@@ -0,0 +1,87 @@
+package databases
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/educolog9/packages/types"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ErrTenancyViolation is wrapped by the errors of filters and documents reaching outside the organization of the tenant.
+// The Repository returns them as ForbiddenError.
+var ErrTenancyViolation = errors.New("tenancy violation")
+
+// resolveTenantScope returns the organization field and value every query of the tenant is restricted to.
+// It returns an empty field when there is no tenant or the tenant bypasses the scope, and an error when
+// the tenant has no organization or any of the filters targets the organization field, so clients
+// cannot override the scope.
+func resolveTenantScope(tenant *types.Tenant, filters []types.Filter) (string, interface{}, error) {
+	if tenant == nil || tenant.IsBypass() {
+		return "", nil, nil
+	}
+
+	if tenant.OrganizationID == "" {
+		return "", nil, fmt.Errorf("missing organization for the tenant scope")
+	}
+
+	field := tenant.GetField()
+	if err := checkTenantFilters(filters, field); err != nil {
+		return "", nil, err
+	}
+
+	return field, tenant.OrganizationID, nil
+}
+
+// checkTenantFilters rejects any filter, at any depth of the filter groups, on the organization field or its subfields.
+func checkTenantFilters(filters []types.Filter, field string) error {
+	for _, f := range filters {
+		if f.IsGroup() {
+			if err := checkTenantFilters(f.Filters, field); err != nil {
+				return err
+			}
+			continue
+		}
+		if f.Field == field || strings.HasPrefix(f.Field, field+".") {
+			return fmt.Errorf("%w: filters on the %s field are not allowed", ErrTenancyViolation, field)
+		}
+	}
+	return nil
+}
+
+// buildTenantDocumentFilter returns the filter selecting the document with the given ID within the tenant scope.
+func buildTenantDocumentFilter(tenant *types.Tenant, id interface{}) (bson.M, error) {
+	filter := bson.M{"_id": resolveRepositoryID(id)}
+
+	field, organization, err := resolveTenantScope(tenant, nil)
+	if err != nil {
+		return nil, err
+	}
+	if field != "" {
+		filter[field] = organization
+	}
+
+	return filter, nil
+}
+
+// checkTenantDocument checks that a document written by the tenant belongs to its organization,
+// so documents cannot be created in, or moved to, another organization.
+func checkTenantDocument(tenant *types.Tenant, document interface{}) error {
+	field, organization, err := resolveTenantScope(tenant, nil)
+	if err != nil || field == "" {
+		return err
+	}
+
+	values, err := marshalMongoDocument(document)
+	if err != nil {
+		return err
+	}
+
+	found, _ := lookupMongoPath(values, field)
+	if len(found) != 1 || compareMongoValues(found[0], organization, nil) != 0 {
+		return fmt.Errorf("%w: the document does not belong to the organization %s", ErrTenancyViolation, tenant.OrganizationID)
+	}
+
+	return nil
+}
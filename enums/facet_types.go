@@ -0,0 +1,18 @@
+package enums
+
+type FacetType string
+
+// Terms represents the facet counting the most frequent values of a field.
+const (
+	Terms         FacetType = "terms"
+	Buckets       FacetType = "buckets"
+	DateHistogram FacetType = "dateHistogram"
+)
+
+// Example of usage in a pagination:
+
+// "facets": [{"name":"byStatus","field":"status","type":"terms","size":5}]
+// "facets": [{"name":"byPrice","field":"price","type":"buckets","boundaries":[0,100,500,1000]}]
+// "facets": [{"name":"perMonth","field":"createdAt","type":"dateHistogram","interval":"month"}]
+
+// Date histogram intervals are minute, hour, day, week, month, quarter and year, truncated in the time zone of the request's country.
@@ -0,0 +1,17 @@
+package enums
+
+type LogicalOperator string
+
+// And represents a group whose filters must all match.
+const (
+	And LogicalOperator = "and"
+	Or  LogicalOperator = "or"
+	Not LogicalOperator = "not"
+)
+
+// Example of usage in a filter group:
+
+// [{"group":"or","filters":[{"field":"status","operator":"eq","value":"active"},{"field":"status","operator":"eq","value":"pending"}]}]
+// [{"field":"age","operator":"gte","value":18},{"field":"age","operator":"lte","value":30}]
+// [{"group":"not","filters":[{"field":"name","operator":"like","value":"test"}]}]
+// [{"group":"and","filters":[{"field":"age","operator":"gte","value":18},{"group":"or","filters":[{"field":"country","operator":"eq","value":"do"},{"field":"country","operator":"eq","value":"us"}]}]}]
@@ -14,6 +14,18 @@ const (
 	NotLike            Operator = "notLike"
 	In                 Operator = "in"
 	NotIn              Operator = "notIn"
+	Exists             Operator = "exists"
+	Between            Operator = "between"
+	StartsWith         Operator = "startsWith"
+	EndsWith           Operator = "endsWith"
+	Contains           Operator = "contains"
+	All                Operator = "all"
+	Size               Operator = "size"
+	ElemMatch          Operator = "elemMatch"
+	Near               Operator = "near"
+	WithinRadius       Operator = "withinRadius"
+	WithinBox          Operator = "withinBox"
+	WithinPolygon      Operator = "withinPolygon"
 )
 
 // Example of usage in a filter:
@@ -28,3 +40,20 @@ const (
 // [{"field":"age","operator":"ne","value":"18"}]
 // [{"field":"age","operator":"notIn","value":"18,19,20"}]
 // [{"field":"age","operator":"gt","value":"18"}]
+// [{"field":"deletedAt","operator":"exists","value":false}]
+// [{"field":"age","operator":"between","value":[18,30]}]
+// [{"field":"createdAt","operator":"between","value":["startOfMonth","now"],"type":"datetime"}]
+// [{"field":"name","operator":"startsWith","value":"Jo"}]
+// [{"field":"email","operator":"endsWith","value":"@gmail.com"}]
+// [{"field":"name","operator":"contains","value":"john"}]
+// [{"field":"tags","operator":"all","value":["go","mongo"]}]
+// [{"field":"tags","operator":"size","value":2}]
+// [{"field":"items","operator":"elemMatch","value":[{"field":"qty","operator":"gte","value":5},{"field":"status","operator":"eq","value":"A"}]}]
+// [{"field":"scores","operator":"elemMatch","value":[{"operator":"gte","value":80},{"operator":"lt","value":90}]}]
+// [{"field":"location","operator":"near","value":{"lat":18.47,"lng":-69.89,"maxDistance":5000}}]
+// [{"field":"location","operator":"near","value":{"type":"Point","coordinates":[-69.89,18.47],"maxDistance":5000}}]
+// [{"field":"location","operator":"withinRadius","value":{"lat":18.47,"lng":-69.89,"radius":5000}}]
+// [{"field":"location","operator":"withinBox","value":{"bottomLeft":{"lat":18.4,"lng":-70},"topRight":{"lat":18.6,"lng":-69.8}}}]
+// [{"field":"location","operator":"withinBox","value":{"bottomLeft":{"lat":-20,"lng":175},"topRight":{"lat":-15,"lng":-178}}}]
+// [{"field":"location","operator":"withinPolygon","value":[{"lat":18.4,"lng":-70},{"lat":18.6,"lng":-70},{"lat":18.6,"lng":-69.8}]}]
+// [{"field":"location","operator":"withinPolygon","value":{"type":"Polygon","coordinates":[[[-70,18.4],[-70,18.6],[-69.8,18.6],[-70,18.4]]]}}]
@@ -0,0 +1,11 @@
+package enums
+
+type PipelinePoint string
+
+// AfterMatch represents the point right after the $search/$match stages, e.g. for $lookup or $addFields.
+const (
+	AfterMatch    PipelinePoint = "afterMatch"
+	AfterSort     PipelinePoint = "afterSort"
+	AfterPaginate PipelinePoint = "afterPaginate"
+	AfterProject  PipelinePoint = "afterProject"
+)
@@ -4,8 +4,28 @@ type CustomTypes string
 
 // Integer represents the custom type for integers.
 const (
-	Integer CustomTypes = "integer"
-	String  CustomTypes = "string"
-	Float   CustomTypes = "float"
-	Boolean CustomTypes = "boolean"
+	Integer  CustomTypes = "integer"
+	String   CustomTypes = "string"
+	Float    CustomTypes = "float"
+	Boolean  CustomTypes = "boolean"
+	ObjectID CustomTypes = "objectId"
+	Date     CustomTypes = "date"
+	DateTime CustomTypes = "datetime"
 )
+
+// Example of usage in a filter:
+
+// [{"field":"age","operator":"gte","value":"18","type":"integer"}]
+// [{"field":"age","operator":"in","value":"18,19,20","type":"integer"}]
+// [{"field":"code","operator":"eq","value":"5f1d7f3e9b1e8a3d4c2b1a09","type":"string"}]
+// [{"field":"userId","operator":"eq","value":"5f1d7f3e9b1e8a3d4c2b1a09","type":"objectId"}]
+// [{"field":"birthDate","operator":"lt","value":"2006-01-02","type":"date"}]
+// [{"field":"createdAt","operator":"gte","value":"2024-01-02T15:04:05Z","type":"datetime"}]
+
+// Date and datetime values also accept relative expressions, resolved in the time zone of the request's country:
+// now, today, startOfDay, endOfDay, startOfWeek, endOfWeek, startOfMonth, endOfMonth, startOfYear and endOfYear,
+// followed by offsets in seconds (s), minutes (m), hours (h), days (d), weeks (w), months (M) or years (y).
+
+// [{"field":"createdAt","operator":"gte","value":"now-7d","type":"datetime"}]
+// [{"field":"createdAt","operator":"lte","value":"endOfDay","type":"datetime"}]
+// [{"field":"birthDate","operator":"gte","value":"startOfMonth-1M","type":"date"}]
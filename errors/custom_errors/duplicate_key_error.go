@@ -2,6 +2,10 @@ package customerrors
 
 type DuplicateKeyError struct {
 	*BaseError
+	// Index is the name of the unique index that was violated, when known.
+	Index string
+	// Key holds the duplicated value of each field of the index, when known.
+	Key map[string]interface{}
 }
 
 // NewDuplicateKeyError creates a new DuplicateKeyError with the given message and inner error.
@@ -0,0 +1,17 @@
+package customerrors
+
+type ForbiddenError struct {
+	*BaseError
+}
+
+// NewForbiddenError creates a new ForbiddenError with the given message and inner error.
+// It returns a pointer to the created ForbiddenError.
+func NewForbiddenError(msg string, inner error) *ForbiddenError {
+	if msg == "" {
+		msg = "Forbidden"
+	}
+
+	return &ForbiddenError{
+		BaseError: NewBaseError(msg, 403, inner),
+	}
+}
@@ -0,0 +1,17 @@
+package customerrors
+
+type GatewayTimeoutError struct {
+	*BaseError
+}
+
+// NewGatewayTimeoutError creates a new GatewayTimeoutError with the given message and inner error.
+// It returns a pointer to the created GatewayTimeoutError.
+func NewGatewayTimeoutError(msg string, inner error) *GatewayTimeoutError {
+	if msg == "" {
+		msg = "Gateway timeout"
+	}
+
+	return &GatewayTimeoutError{
+		BaseError: NewBaseError(msg, 504, inner),
+	}
+}
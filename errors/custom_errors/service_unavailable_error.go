@@ -0,0 +1,17 @@
+package customerrors
+
+type ServiceUnavailableError struct {
+	*BaseError
+}
+
+// NewServiceUnavailableError creates a new ServiceUnavailableError with the given message and inner error.
+// It returns a pointer to the created ServiceUnavailableError.
+func NewServiceUnavailableError(msg string, inner error) *ServiceUnavailableError {
+	if msg == "" {
+		msg = "Service unavailable"
+	}
+
+	return &ServiceUnavailableError{
+		BaseError: NewBaseError(msg, 503, inner),
+	}
+}
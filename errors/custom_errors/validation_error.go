@@ -0,0 +1,17 @@
+package customerrors
+
+type ValidationError struct {
+	*BaseError
+}
+
+// NewValidationError creates a new ValidationError with the given message and inner error.
+// It returns a pointer to the created ValidationError.
+func NewValidationError(msg string, inner error) *ValidationError {
+	if msg == "" {
+		msg = "Validation failed"
+	}
+
+	return &ValidationError{
+		BaseError: NewBaseError(msg, 400, inner),
+	}
+}
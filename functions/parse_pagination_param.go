@@ -10,13 +10,17 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// ParsePaginationParams reads the Pagination encoded as base64 JSON in the `p` query param.
+// When `p` is missing, the readable query params are read instead (see ParsePaginationQuery).
+// A missing limit is left at zero, which GetLimit reads as types.DefaultPaginationLimit.
 func ParsePaginationParams(c *gin.Context) (*types.Pagination, error) {
 	paginationEncode := c.Query("p")
 
 	var p types.Pagination
 
-	if paginationEncode == "" {
-		p.Limit = 10
+	if paginationEncode == "" && HasPaginationQuery(c) {
+		return ParsePaginationQuery(c)
+	} else if paginationEncode == "" {
 		p.Order = enums.Asc
 	} else {
 		decodedData, err := base64.URLEncoding.DecodeString(paginationEncode)
@@ -36,6 +40,15 @@ func ParsePaginationParams(c *gin.Context) (*types.Pagination, error) {
 				return nil, fmt.Errorf("invalid order format")
 			}
 		}
+
+		for _, sort := range p.Sorts {
+			if sort.Field == "" {
+				return nil, fmt.Errorf("invalid sort format: missing field")
+			}
+			if sort.Order != "" && sort.Order != enums.Asc && sort.Order != enums.Desc {
+				return nil, fmt.Errorf("invalid order format for sort field %s", sort.Field)
+			}
+		}
 	}
 
 	return &p, nil
@@ -0,0 +1,202 @@
+package functions
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/educolog9/packages/enums"
+	"github.com/educolog9/packages/types"
+	"github.com/gin-gonic/gin"
+)
+
+// paginationQueryParams are the query params read by ParsePaginationQuery, besides the filter[...] params.
+var paginationQueryParams = []string{"limit", "offset", "sort", "q", "filter", "fields", "next", "prev"}
+
+// HasPaginationQuery checks if the request carries any of the query params read by ParsePaginationQuery.
+func HasPaginationQuery(c *gin.Context) bool {
+	for _, param := range paginationQueryParams {
+		if _, ok := c.GetQuery(param); ok {
+			return true
+		}
+	}
+	for key := range c.Request.URL.Query() {
+		if strings.HasPrefix(key, "filter[") {
+			return true
+		}
+	}
+	return false
+}
+
+// ParsePaginationQuery reads a Pagination from conventional query params, as a readable alternative to `p`:
+//
+//	limit=20&offset=40
+//	sort=-createdAt,name            (a leading "-" sorts descending)
+//	q=john                          (search text)
+//	fields=name,email               (or fields=-password to exclude fields)
+//	filter[age][gte]=18             (filter[field]=value is a shortcut for the eq operator)
+//	filter[age][gte][integer]=18    (an optional type hint, see enums.CustomTypes)
+//	filter[status][in]=active,new   (list operators take comma separated values)
+//	filter=age=ge=18;(country==do,country==us)   (RSQL/FIQL, see ParseRSQL)
+//	next=<cursor> or prev=<cursor>
+//
+// Filter values that are unambiguous numbers or booleans, such as 18, -2.5 or true, are decoded so the
+// converters compare them with numeric and boolean fields; any other value, such as "007" or "1e3", is kept
+// as a string. The type hint of a filter, or the field types of a PaginationSchema, coerce them further.
+// Values written as JSON objects or arrays, such as the GeoJSON of a near filter, are decoded.
+// Bracket filters and the RSQL expression are ANDed.
+func ParsePaginationQuery(c *gin.Context) (*types.Pagination, error) {
+	p := types.Pagination{
+		Order: enums.Asc,
+		Next:  c.Query("next"),
+		Prev:  c.Query("prev"),
+	}
+
+	if limit, ok := c.GetQuery("limit"); ok {
+		value, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid limit format")
+		}
+		p.Limit = value
+	}
+
+	if offset, ok := c.GetQuery("offset"); ok {
+		value, err := strconv.ParseInt(offset, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid offset format")
+		}
+		p.Offset = value
+	}
+
+	p.Search = c.Query("q")
+
+	for _, field := range splitQueryList(c.Query("sort")) {
+		order := enums.Asc
+		if strings.HasPrefix(field, "-") {
+			order = enums.Desc
+			field = field[1:]
+		} else {
+			field = strings.TrimPrefix(field, "+")
+		}
+		if field == "" {
+			return nil, fmt.Errorf("invalid sort format: missing field")
+		}
+		p.Sorts = append(p.Sorts, types.SortField{Field: field, Order: order})
+	}
+
+	for _, field := range splitQueryList(c.Query("fields")) {
+		if strings.HasPrefix(field, "-") {
+			p.ExcludeFields = append(p.ExcludeFields, field[1:])
+		} else {
+			p.Fields = append(p.Fields, field)
+		}
+	}
+	if len(p.Fields) > 0 && len(p.ExcludeFields) > 0 {
+		return nil, fmt.Errorf("invalid fields format: fields cannot be included and excluded at the same time")
+	}
+
+	filters, err := parseQueryFilters(c)
+	if err != nil {
+		return nil, err
+	}
+	p.Filters = filters
+
+	if expression := c.Query("filter"); expression != "" {
+		rsqlFilters, err := ParseRSQL(expression)
+		if err != nil {
+			return nil, err
+		}
+		p.Filters = append(p.Filters, rsqlFilters...)
+	}
+
+	return &p, nil
+}
+
+// parseQueryFilters reads the filter[field], filter[field][operator] and filter[field][operator][type] query params.
+// The params are read in key order, so the filters are returned in a stable order.
+func parseQueryFilters(c *gin.Context) ([]types.Filter, error) {
+	query := c.Request.URL.Query()
+
+	var keys []string
+	for key := range query {
+		if strings.HasPrefix(key, "filter[") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var filters []types.Filter
+	for _, key := range keys {
+		parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(key, "filter["), "]"), "][")
+
+		field, operator, valueType := parts[0], enums.Equal, enums.CustomTypes("")
+		if len(parts) >= 2 {
+			operator = enums.Operator(parts[1])
+		}
+		if len(parts) == 3 {
+			valueType = enums.CustomTypes(parts[2])
+		}
+		if field == "" || operator == "" || len(parts) > 3 || (len(parts) == 3 && valueType == "") || !strings.HasSuffix(key, "]") {
+			return nil, fmt.Errorf("invalid filter format: %s", key)
+		}
+
+		for _, raw := range query[key] {
+			value := decodeQueryValue(raw)
+			switch operator {
+			case enums.In, enums.NotIn, enums.Between, enums.All:
+				if str, ok := value.(string); ok {
+					var list []interface{}
+					for _, item := range strings.Split(str, ",") {
+						list = append(list, decodeQueryValue(strings.TrimSpace(item)))
+					}
+					value = list
+				}
+			}
+
+			filters = append(filters, types.Filter{Field: field, Operator: operator, Value: value, Type: valueType})
+		}
+	}
+
+	return filters, nil
+}
+
+// decodeQueryValue decodes a query value as JSON when it is a JSON object or array, as a boolean when it is
+// true or false, and as a number when it is written the way the number is formatted back, so values such as
+// "007", "1.50" or "1e3", which are likely codes, keep their exact text. It returns the string otherwise.
+func decodeQueryValue(value string) interface{} {
+	switch value {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+
+	if number, err := strconv.ParseFloat(value, 64); err == nil && !math.IsNaN(number) && !math.IsInf(number, 0) && strconv.FormatFloat(number, 'f', -1, 64) == value {
+		return number
+	}
+
+	trimmed := strings.TrimSpace(value)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return value
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(trimmed), &decoded); err == nil {
+		return decoded
+	}
+	return value
+}
+
+// splitQueryList splits a comma separated query value, dropping empty items.
+func splitQueryList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,118 @@
+package functions
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/educolog9/packages/enums"
+	"github.com/educolog9/packages/types"
+	"github.com/gin-gonic/gin"
+)
+
+func TestDecodeQueryValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  interface{}
+	}{
+		{value: "18", want: float64(18)},
+		{value: "-2.5", want: -2.5},
+		{value: "0", want: float64(0)},
+		{value: "true", want: true},
+		{value: "false", want: false},
+		{value: "007", want: "007"},
+		{value: "1.50", want: "1.50"},
+		{value: "1e3", want: "1e3"},
+		{value: "+1", want: "+1"},
+		{value: "NaN", want: "NaN"},
+		{value: "+Inf", want: "+Inf"},
+		{value: "True", want: "True"},
+		{value: "john", want: "john"},
+		{value: `{"lng":1,"lat":2}`, want: map[string]interface{}{"lng": float64(1), "lat": float64(2)}},
+		{value: "[1,2]", want: []interface{}{float64(1), float64(2)}},
+		{value: "[not json", want: "[not json"},
+	}
+
+	for _, tt := range tests {
+		if got := decodeQueryValue(tt.value); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("decodeQueryValue(%q) = %#v, want %#v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParsePaginationQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		query   string
+		want    *types.Pagination
+		wantErr bool
+	}{
+		{
+			name:  "empty",
+			query: "",
+			want:  &types.Pagination{Order: enums.Asc},
+		},
+		{
+			name:  "limit, offset, search and sort",
+			query: "limit=20&offset=40&q=john&sort=-createdAt,%2Bname",
+			want: &types.Pagination{
+				Limit:  20,
+				Offset: 40,
+				Search: "john",
+				Order:  enums.Asc,
+				Sorts:  []types.SortField{{Field: "createdAt", Order: enums.Desc}, {Field: "name", Order: enums.Asc}},
+			},
+		},
+		{
+			name:  "fields and cursor",
+			query: "fields=-password,-token&next=abc",
+			want:  &types.Pagination{Order: enums.Asc, Next: "abc", ExcludeFields: []string{"password", "token"}},
+		},
+		{
+			name:  "bracket filters",
+			query: "filter[name]=john&filter[age][gte][integer]=18&filter[status][in]=active,%20new&filter[code]=007",
+			want: &types.Pagination{Order: enums.Asc, Filters: []types.Filter{
+				{Field: "age", Operator: enums.GreaterThanOrEqual, Value: float64(18), Type: enums.CustomTypes("integer")},
+				{Field: "code", Operator: enums.Equal, Value: "007"},
+				{Field: "name", Operator: enums.Equal, Value: "john"},
+				{Field: "status", Operator: enums.In, Value: []interface{}{"active", "new"}},
+			}},
+		},
+		{
+			name:  "bracket and RSQL filters are ANDed",
+			query: "filter[active]=true&filter=age%3Dge%3D18%3B(country%3D%3Ddo,country%3D%3Dus)",
+			want: &types.Pagination{Order: enums.Asc, Filters: []types.Filter{
+				{Field: "active", Operator: enums.Equal, Value: true},
+				{Field: "age", Operator: enums.GreaterThanOrEqual, Value: float64(18)},
+				{Group: enums.Or, Filters: []types.Filter{
+					{Field: "country", Operator: enums.Equal, Value: "do"},
+					{Field: "country", Operator: enums.Equal, Value: "us"},
+				}},
+			}},
+		},
+		{name: "invalid limit", query: "limit=ten", wantErr: true},
+		{name: "invalid offset", query: "offset=-", wantErr: true},
+		{name: "sort without field", query: "sort=-", wantErr: true},
+		{name: "included and excluded fields", query: "fields=name,-password", wantErr: true},
+		{name: "invalid bracket filter", query: "filter[age][gte][integer][x]=1", wantErr: true},
+		{name: "unclosed bracket filter", query: "filter[age=1", wantErr: true},
+		{name: "invalid RSQL filter", query: "filter=age%3D%3D", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/?"+tt.query, nil)
+
+			got, err := ParsePaginationQuery(c)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePaginationQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePaginationQuery() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package functions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/educolog9/packages/enums"
+	"github.com/educolog9/packages/types"
+)
+
+// rsqlOperators maps the RSQL/FIQL comparison operators to the filter operators.
+// Any other operator written as =name= is read as the filter operator of that name, e.g. =startsWith= or =exists=.
+var rsqlOperators = map[string]enums.Operator{
+	"==":     enums.Equal,
+	"!=":     enums.NotEqual,
+	"=gt=":   enums.GreaterThan,
+	"=ge=":   enums.GreaterThanOrEqual,
+	"=lt=":   enums.LessThan,
+	"=le=":   enums.LessThanOrEqual,
+	"=in=":   enums.In,
+	"=out=":  enums.NotIn,
+	">":      enums.GreaterThan,
+	">=":     enums.GreaterThanOrEqual,
+	"<":      enums.LessThan,
+	"<=":     enums.LessThanOrEqual,
+	"=like=": enums.Like,
+}
+
+// ParseRSQL parses an RSQL/FIQL expression into filters, e.g. `age=ge=18;(country==do,country==us)`.
+// ";" (or "and") joins constraints with AND, "," (or "or") joins them with OR, and parentheses group them.
+// Values can be quoted with single or double quotes, and lists are written as (a,b,c). An unquoted
+// value with a leading or trailing "*" on == is read as endsWith, startsWith or contains.
+// Unquoted values that are unambiguous numbers or booleans are decoded, as in ParsePaginationQuery, while
+// quoted values are always strings. Spaces around the values of a list are ignored, e.g. (a, b).
+// Groups can be nested up to maxRSQLDepth levels.
+func ParseRSQL(expression string) ([]types.Filter, error) {
+	parser := &rsqlParser{input: expression}
+
+	filter, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	parser.skipSpaces()
+	if parser.pos < len(parser.input) {
+		return nil, fmt.Errorf("invalid filter expression: unexpected %q at position %d", parser.input[parser.pos], parser.pos)
+	}
+
+	if filter.Group == enums.And {
+		return filter.Filters, nil
+	}
+	return []types.Filter{filter}, nil
+}
+
+// maxRSQLDepth is the maximum nesting of parenthesized groups in an RSQL expression,
+// which keeps the recursion of the parser bounded.
+const maxRSQLDepth = 32
+
+// rsqlParser is a recursive descent parser of RSQL/FIQL expressions.
+type rsqlParser struct {
+	input string
+	pos   int
+	depth int
+}
+
+func (p *rsqlParser) parseOr() (types.Filter, error) {
+	return p.parseList(enums.Or, ",", "or", p.parseAnd)
+}
+
+func (p *rsqlParser) parseAnd() (types.Filter, error) {
+	return p.parseList(enums.And, ";", "and", p.parseConstraint)
+}
+
+// parseList parses one or more operands separated by the symbol or keyword of a logical operator.
+// A single operand is returned as it is, without a group.
+func (p *rsqlParser) parseList(group enums.LogicalOperator, symbol string, keyword string, operand func() (types.Filter, error)) (types.Filter, error) {
+	first, err := operand()
+	if err != nil {
+		return types.Filter{}, err
+	}
+
+	filters := []types.Filter{first}
+	for p.consumeSeparator(symbol, keyword) {
+		next, err := operand()
+		if err != nil {
+			return types.Filter{}, err
+		}
+		filters = append(filters, next)
+	}
+
+	if len(filters) == 1 {
+		return first, nil
+	}
+	return types.Filter{Group: group, Filters: filters}, nil
+}
+
+func (p *rsqlParser) parseConstraint() (types.Filter, error) {
+	p.skipSpaces()
+
+	if p.consume("(") {
+		p.depth++
+		if p.depth > maxRSQLDepth {
+			return types.Filter{}, fmt.Errorf("invalid filter expression: groups nested deeper than %d levels", maxRSQLDepth)
+		}
+
+		filter, err := p.parseOr()
+		p.depth--
+		if err != nil {
+			return types.Filter{}, err
+		}
+		p.skipSpaces()
+		if !p.consume(")") {
+			return types.Filter{}, fmt.Errorf("invalid filter expression: missing ')' at position %d", p.pos)
+		}
+		if !filter.IsGroup() {
+			filter = types.Filter{Group: enums.And, Filters: []types.Filter{filter}}
+		}
+		return filter, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.input) && !strings.ContainsRune("=!<>();, ", rune(p.input[p.pos])) {
+		p.pos++
+	}
+	field := p.input[start:p.pos]
+	if field == "" {
+		return types.Filter{}, fmt.Errorf("invalid filter expression: missing field at position %d", p.pos)
+	}
+
+	operator, err := p.parseOperator()
+	if err != nil {
+		return types.Filter{}, err
+	}
+
+	value, quoted, err := p.parseArguments()
+	if err != nil {
+		return types.Filter{}, err
+	}
+
+	filter := types.Filter{Field: field, Operator: operator, Value: value}
+
+	if str, ok := value.(string); ok && !quoted && operator == enums.Equal && strings.Contains(str, "*") {
+		trimmed := strings.Trim(str, "*")
+		switch {
+		case strings.HasPrefix(str, "*") && strings.HasSuffix(str, "*"):
+			filter.Operator = enums.Contains
+		case strings.HasPrefix(str, "*"):
+			filter.Operator = enums.EndsWith
+		case strings.HasSuffix(str, "*"):
+			filter.Operator = enums.StartsWith
+		}
+		if filter.Operator != enums.Equal {
+			filter.Value = trimmed
+		}
+	}
+
+	return filter, nil
+}
+
+func (p *rsqlParser) parseOperator() (enums.Operator, error) {
+	rest := p.input[p.pos:]
+
+	for _, symbol := range []string{"==", "!=", ">=", "<=", ">", "<"} {
+		if strings.HasPrefix(rest, symbol) {
+			p.pos += len(symbol)
+			return rsqlOperators[symbol], nil
+		}
+	}
+
+	if strings.HasPrefix(rest, "=") {
+		end := strings.Index(rest[1:], "=")
+		if end > 0 {
+			name := rest[:end+2]
+			p.pos += len(name)
+			if operator, ok := rsqlOperators[name]; ok {
+				return operator, nil
+			}
+			return enums.Operator(strings.Trim(name, "=")), nil
+		}
+	}
+
+	return "", fmt.Errorf("invalid filter expression: missing operator at position %d", p.pos)
+}
+
+// parseArguments parses a single value or a list of values in parentheses.
+// It reports whether a single value was quoted.
+func (p *rsqlParser) parseArguments() (interface{}, bool, error) {
+	if p.consume("(") {
+		var values []interface{}
+		for {
+			p.skipSpaces()
+			value, _, err := p.parseValue()
+			if err != nil {
+				return nil, false, err
+			}
+			values = append(values, value)
+			p.skipSpaces()
+			if !p.consume(",") {
+				break
+			}
+		}
+		if !p.consume(")") {
+			return nil, false, fmt.Errorf("invalid filter expression: missing ')' at position %d", p.pos)
+		}
+		return values, false, nil
+	}
+
+	return p.parseValue()
+}
+
+func (p *rsqlParser) parseValue() (interface{}, bool, error) {
+	if p.pos < len(p.input) && (p.input[p.pos] == '\'' || p.input[p.pos] == '"') {
+		quote := p.input[p.pos]
+		p.pos++
+
+		var b strings.Builder
+		for p.pos < len(p.input) && p.input[p.pos] != quote {
+			if p.input[p.pos] == '\\' && p.pos+1 < len(p.input) {
+				p.pos++
+			}
+			b.WriteByte(p.input[p.pos])
+			p.pos++
+		}
+		if !p.consume(string(quote)) {
+			return nil, false, fmt.Errorf("invalid filter expression: unterminated string")
+		}
+		return b.String(), true, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.input) && !strings.ContainsRune("();, ", rune(p.input[p.pos])) {
+		p.pos++
+	}
+	if start == p.pos {
+		return nil, false, fmt.Errorf("invalid filter expression: missing value at position %d", p.pos)
+	}
+
+	return decodeQueryValue(p.input[start:p.pos]), false, nil
+}
+
+// consumeSeparator consumes the symbol or the keyword, surrounded by spaces, of a logical operator.
+func (p *rsqlParser) consumeSeparator(symbol string, keyword string) bool {
+	start := p.pos
+	p.skipSpaces()
+	if p.consume(symbol) {
+		return true
+	}
+
+	if p.pos > start && strings.HasPrefix(p.input[p.pos:], keyword+" ") {
+		p.pos += len(keyword)
+		return true
+	}
+
+	p.pos = start
+	return false
+}
+
+func (p *rsqlParser) consume(token string) bool {
+	if strings.HasPrefix(p.input[p.pos:], token) {
+		p.pos += len(token)
+		return true
+	}
+	return false
+}
+
+func (p *rsqlParser) skipSpaces() {
+	for p.pos < len(p.input) && p.input[p.pos] == ' ' {
+		p.pos++
+	}
+}
@@ -0,0 +1,167 @@
+package functions
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/educolog9/packages/enums"
+	"github.com/educolog9/packages/types"
+)
+
+func TestParseRSQL(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		want       []types.Filter
+		wantErr    bool
+	}{
+		{
+			name:       "single constraint",
+			expression: "name==john",
+			want:       []types.Filter{{Field: "name", Operator: enums.Equal, Value: "john"}},
+		},
+		{
+			name:       "comparison operators",
+			expression: "age=ge=18;age<65;score!=0;level=gt=1.5",
+			want: []types.Filter{
+				{Field: "age", Operator: enums.GreaterThanOrEqual, Value: float64(18)},
+				{Field: "age", Operator: enums.LessThan, Value: float64(65)},
+				{Field: "score", Operator: enums.NotEqual, Value: float64(0)},
+				{Field: "level", Operator: enums.GreaterThan, Value: 1.5},
+			},
+		},
+		{
+			name:       "named operators",
+			expression: "email=exists=true;name=startsWith=jo;bio=like=go",
+			want: []types.Filter{
+				{Field: "email", Operator: enums.Exists, Value: true},
+				{Field: "name", Operator: enums.StartsWith, Value: "jo"},
+				{Field: "bio", Operator: enums.Like, Value: "go"},
+			},
+		},
+		{
+			name:       "and binds tighter than or",
+			expression: "a==1;b==2,c==3",
+			want: []types.Filter{{Group: enums.Or, Filters: []types.Filter{
+				{Group: enums.And, Filters: []types.Filter{
+					{Field: "a", Operator: enums.Equal, Value: float64(1)},
+					{Field: "b", Operator: enums.Equal, Value: float64(2)},
+				}},
+				{Field: "c", Operator: enums.Equal, Value: float64(3)},
+			}}},
+		},
+		{
+			name:       "parenthesized group",
+			expression: "age=ge=18;(country==do,country==us)",
+			want: []types.Filter{
+				{Field: "age", Operator: enums.GreaterThanOrEqual, Value: float64(18)},
+				{Group: enums.Or, Filters: []types.Filter{
+					{Field: "country", Operator: enums.Equal, Value: "do"},
+					{Field: "country", Operator: enums.Equal, Value: "us"},
+				}},
+			},
+		},
+		{
+			name:       "keywords",
+			expression: "a==1 and (b==2 or c==3)",
+			want: []types.Filter{
+				{Field: "a", Operator: enums.Equal, Value: float64(1)},
+				{Group: enums.Or, Filters: []types.Filter{
+					{Field: "b", Operator: enums.Equal, Value: float64(2)},
+					{Field: "c", Operator: enums.Equal, Value: float64(3)},
+				}},
+			},
+		},
+		{
+			name:       "redundant parentheses",
+			expression: "(a==1)",
+			want:       []types.Filter{{Field: "a", Operator: enums.Equal, Value: float64(1)}},
+		},
+		{
+			name:       "nested groups",
+			expression: "((a==1,b==2);c==3)",
+			want: []types.Filter{
+				{Group: enums.Or, Filters: []types.Filter{
+					{Field: "a", Operator: enums.Equal, Value: float64(1)},
+					{Field: "b", Operator: enums.Equal, Value: float64(2)},
+				}},
+				{Field: "c", Operator: enums.Equal, Value: float64(3)},
+			},
+		},
+		{
+			name:       "lists with spaces",
+			expression: "status=in=(active, new , 3);role=out=('a b',\"c\")",
+			want: []types.Filter{
+				{Field: "status", Operator: enums.In, Value: []interface{}{"active", "new", float64(3)}},
+				{Field: "role", Operator: enums.NotIn, Value: []interface{}{"a b", "c"}},
+			},
+		},
+		{
+			name:       "quoted values stay strings",
+			expression: `code=='007';zip=="10001";flag=='true';name=='it\'s;ok'`,
+			want: []types.Filter{
+				{Field: "code", Operator: enums.Equal, Value: "007"},
+				{Field: "zip", Operator: enums.Equal, Value: "10001"},
+				{Field: "flag", Operator: enums.Equal, Value: "true"},
+				{Field: "name", Operator: enums.Equal, Value: "it's;ok"},
+			},
+		},
+		{
+			name:       "ambiguous numbers stay strings",
+			expression: "code==007;price==1.50;exp==1e3;active==false",
+			want: []types.Filter{
+				{Field: "code", Operator: enums.Equal, Value: "007"},
+				{Field: "price", Operator: enums.Equal, Value: "1.50"},
+				{Field: "exp", Operator: enums.Equal, Value: "1e3"},
+				{Field: "active", Operator: enums.Equal, Value: false},
+			},
+		},
+		{
+			name:       "wildcards",
+			expression: "name==jo*;name==*son;name==*oh*;name=='jo*'",
+			want: []types.Filter{
+				{Field: "name", Operator: enums.StartsWith, Value: "jo"},
+				{Field: "name", Operator: enums.EndsWith, Value: "son"},
+				{Field: "name", Operator: enums.Contains, Value: "oh"},
+				{Field: "name", Operator: enums.Equal, Value: "jo*"},
+			},
+		},
+		{
+			name:       "dotted fields",
+			expression: "address.city==santo",
+			want:       []types.Filter{{Field: "address.city", Operator: enums.Equal, Value: "santo"}},
+		},
+		{
+			name:       "nesting at the depth limit",
+			expression: strings.Repeat("(", maxRSQLDepth) + "a==1" + strings.Repeat(")", maxRSQLDepth),
+			want:       []types.Filter{{Field: "a", Operator: enums.Equal, Value: float64(1)}},
+		},
+		{
+			name:       "nesting beyond the depth limit",
+			expression: strings.Repeat("(", maxRSQLDepth+1) + "a==1" + strings.Repeat(")", maxRSQLDepth+1),
+			wantErr:    true,
+		},
+		{name: "empty expression", expression: "", wantErr: true},
+		{name: "missing field", expression: "==1", wantErr: true},
+		{name: "missing operator", expression: "name", wantErr: true},
+		{name: "missing value", expression: "name==", wantErr: true},
+		{name: "missing closing parenthesis", expression: "(a==1;b==2", wantErr: true},
+		{name: "unclosed list", expression: "a=in=(1,2", wantErr: true},
+		{name: "unterminated string", expression: "name=='john", wantErr: true},
+		{name: "trailing separator", expression: "a==1;", wantErr: true},
+		{name: "trailing input", expression: "a==1)", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRSQL(tt.expression)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRSQL(%q) error = %v, wantErr %v", tt.expression, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseRSQL(%q) = %#v, want %#v", tt.expression, got, tt.want)
+			}
+		})
+	}
+}
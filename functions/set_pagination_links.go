@@ -0,0 +1,136 @@
+package functions
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/educolog9/packages/types"
+	"github.com/gin-gonic/gin"
+)
+
+// EncodePaginationParams encodes a Pagination as the `p` query param, the same way ParsePaginationParams decodes it.
+func EncodePaginationParams(p *types.Pagination) (string, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return "", fmt.Errorf("invalid pagination data: %w", err)
+	}
+
+	return base64.URLEncoding.EncodeToString(data), nil
+}
+
+// SetPaginationLinks sets the RFC 8288 Link header (first, prev, next and last) and the X-Total-Count
+// header of a paginated response, and fills the next and prev URLs of the response.
+// The links are relative to the host, pointing to the path of the current request with the pagination
+// encoded in the `p` param, so they never depend on the client-controlled Host or X-Forwarded-Proto headers;
+// the other query params are kept, while the readable pagination params read by ParsePaginationQuery are dropped.
+// Offset pages link to the neighbour offsets and to the last page. Cursor pages link to the next and prev
+// cursors of the response, and have no last link since the position of the last page is not known.
+// When the ParsePaginationParams middleware ran, the links encode the pagination as the client sent it,
+// with its API field names, instead of the given pagination whose fields were mapped to document paths.
+func SetPaginationLinks[T any](c *gin.Context, pagination *types.Pagination, response *types.PaginatedResponse[T]) error {
+	if requested, ok := c.Get("requestPagination"); ok {
+		if requestPagination, ok := requested.(*types.Pagination); ok {
+			pagination = requestPagination
+		}
+	}
+
+	links := map[string]*types.Pagination{}
+
+	first := *pagination
+	first.Offset, first.Next, first.Prev = 0, "", ""
+	links["first"] = &first
+
+	if pagination.HasCursor() || response.Next != "" || response.Prev != "" {
+		if response.Next != "" {
+			next := first
+			next.Next = response.Next
+			links["next"] = &next
+		}
+		if response.Prev != "" {
+			prev := first
+			prev.Prev = response.Prev
+			links["prev"] = &prev
+		}
+	} else {
+		limit := response.Limit
+		if limit <= 0 {
+			limit = pagination.GetLimit()
+		}
+
+		if response.HasMore {
+			next := first
+			next.Offset = response.Offset + limit
+			links["next"] = &next
+		}
+		if response.Offset > 0 {
+			prev := first
+			prev.Offset = response.Offset - limit
+			if prev.Offset < 0 {
+				prev.Offset = 0
+			}
+			links["prev"] = &prev
+		}
+		if response.Total > 0 {
+			last := first
+			last.Offset = (response.Total - 1) / limit * limit
+			links["last"] = &last
+		}
+	}
+
+	var header []string
+	for _, rel := range []string{"first", "prev", "next", "last"} {
+		link, ok := links[rel]
+		if !ok {
+			continue
+		}
+
+		linkURL, err := buildPaginationURL(c, link)
+		if err != nil {
+			return err
+		}
+
+		header = append(header, fmt.Sprintf(`<%s>; rel="%s"`, linkURL, rel))
+		switch rel {
+		case "next":
+			response.NextURL = linkURL
+		case "prev":
+			response.PrevURL = linkURL
+		}
+	}
+
+	c.Header("Link", strings.Join(header, ", "))
+	c.Header("X-Total-Count", strconv.FormatInt(response.Total, 10))
+
+	return nil
+}
+
+// buildPaginationURL returns the path of the current request with the given pagination in the `p` param.
+func buildPaginationURL(c *gin.Context, pagination *types.Pagination) (string, error) {
+	encoded, err := EncodePaginationParams(pagination)
+	if err != nil {
+		return "", err
+	}
+
+	query := c.Request.URL.Query()
+	for key := range query {
+		if strings.HasPrefix(key, "filter[") {
+			query.Del(key)
+		}
+	}
+	for _, param := range paginationQueryParams {
+		query.Del(param)
+	}
+	query.Set("p", encoded)
+
+	// Leading slashes are collapsed so a request to "//host/path" cannot produce a protocol-relative link.
+	link := url.URL{
+		Path:     "/" + strings.TrimLeft(c.Request.URL.Path, "/"),
+		RawQuery: query.Encode(),
+	}
+
+	return link.String(), nil
+}
@@ -1,7 +1,10 @@
 package middlewares
 
 import (
+	"context"
+
 	"github.com/educolog9/packages/countries"
+	"github.com/educolog9/packages/types"
 	"github.com/gin-gonic/gin"
 )
 
@@ -18,6 +21,7 @@ func CountryMiddleware() gin.HandlerFunc {
 
 		// Set the country in the context
 		c.Set("country", country)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), types.CountryCodeKey, country))
 
 		c.Next()
 	}
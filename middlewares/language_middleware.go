@@ -1,7 +1,10 @@
 package middlewares
 
 import (
+	"context"
+
 	languagues "github.com/educolog9/packages/languages"
+	"github.com/educolog9/packages/types"
 	"github.com/gin-gonic/gin"
 )
 
@@ -18,6 +21,7 @@ func LanguageMiddleware() gin.HandlerFunc {
 
 		// Set the language in the context
 		c.Set("language", lang)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), types.ContentLanguageKey, lang))
 
 		c.Next()
 	}
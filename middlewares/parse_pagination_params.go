@@ -3,14 +3,38 @@ package middlewares
 import (
 	"net/http"
 
+	"github.com/educolog9/packages/enums"
+	"github.com/educolog9/packages/errors/messages"
 	"github.com/educolog9/packages/functions"
+	languagues "github.com/educolog9/packages/languages"
+	"github.com/educolog9/packages/types"
+	"github.com/educolog9/packages/validations"
 	"github.com/gin-gonic/gin"
 )
 
 // ParsePaginationParams is a middleware function that parses pagination parameters from the request.
 // It extracts the pagination parameters from the request and sets them in the context for further processing.
+// The parameters are read from the base64 `p` param or, when it is missing, from readable query params
+// such as limit, sort and filter[field][operator] or an RSQL filter expression.
 // If there is an error while parsing the parameters, it returns a bad request error.
-func ParsePaginationParams() gin.HandlerFunc {
+// When a schema is given, the filters and sorts are validated against it and their fields are mapped
+// to document paths; violations are returned as a translated bad request error.
+// Negative limits and offsets are always rejected; use ParsePaginationParamsWithOptions to cap them.
+func ParsePaginationParams(schema ...*types.PaginationSchema) gin.HandlerFunc {
+	options := &types.PaginationOptions{}
+	if len(schema) > 0 {
+		options.Schema = schema[0]
+	}
+
+	return ParsePaginationParamsWithOptions(options)
+}
+
+// ParsePaginationParamsWithOptions is ParsePaginationParams with per-route options: the default and maximum
+// limit, the maximum offset, and a required or default sort. Out of range values are returned as a translated
+// bad request error, or clamped into range when the options say so. See validations.ValidatePaginationOptions.
+// The pagination is set in the "pagination" key of the context with its fields mapped to document paths,
+// and in the "requestPagination" key with the API field names the client sent.
+func ParsePaginationParamsWithOptions(options *types.PaginationOptions) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		pagination, err := functions.ParsePaginationParams(c)
 		if err != nil {
@@ -19,7 +43,108 @@ func ParsePaginationParams() gin.HandlerFunc {
 			return
 		}
 
+		violations := validations.ValidatePaginationOptions(pagination, options)
+
+		if options.Schema != nil {
+			violations = append(violations, validations.ValidatePagination(pagination, options.Schema)...)
+		}
+
+		if len(violations) > 0 {
+			language, ok := c.Get("language")
+			if !ok {
+				language = languagues.Spanish.String()
+			}
+
+			trans, _ := validations.Uni.GetTranslator(language.(string))
+
+			var errors []string
+			for _, violation := range violations {
+				errors = append(errors, violation.Translate(trans))
+			}
+
+			response := types.ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: messages.ValidationFailed,
+				Errors:  errors,
+			}
+			c.JSON(http.StatusBadRequest, response)
+			c.Abort()
+			return
+		}
+
+		// The pagination is kept with the API field names the client sent for the links of
+		// functions.SetPaginationLinks, while the handlers get it with document paths.
+		c.Set("requestPagination", pagination)
+		if options.Schema != nil {
+			pagination = resolvePaginationPaths(pagination, options.Schema)
+		}
+
 		c.Set("pagination", pagination)
 		c.Next()
 	}
 }
+
+// resolvePaginationPaths returns a copy of a pagination validated against the schema with its API field names
+// mapped to document paths, and its filters with the type and match options of their fields, so it can be
+// passed directly to the databases converters.
+func resolvePaginationPaths(pagination *types.Pagination, schema *types.PaginationSchema) *types.Pagination {
+	resolved := pagination.Clone()
+	resolved.Filters = resolveFilterPaths(resolved.Filters, schema)
+
+	if resolved.Sort != "" {
+		resolved.Sort = resolveFieldPath(resolved.Sort, schema)
+	}
+	for i := range resolved.Sorts {
+		resolved.Sorts[i].Field = resolveFieldPath(resolved.Sorts[i].Field, schema)
+	}
+	for i := range resolved.Fields {
+		resolved.Fields[i] = resolveFieldPath(resolved.Fields[i], schema)
+	}
+	for i := range resolved.ExcludeFields {
+		resolved.ExcludeFields[i] = resolveFieldPath(resolved.ExcludeFields[i], schema)
+	}
+	for i := range resolved.Facets {
+		resolved.Facets[i].Field = resolveFieldPath(resolved.Facets[i].Field, schema)
+	}
+
+	return resolved
+}
+
+// resolveFilterPaths maps the fields of the filters to document paths and sets the type and match options of
+// their fields. The filters of elemMatch filters are resolved against the Elements schema of their field.
+func resolveFilterPaths(filters []types.Filter, schema *types.PaginationSchema) []types.Filter {
+	for i := range filters {
+		f := &filters[i]
+
+		if f.IsGroup() {
+			f.Filters = resolveFilterPaths(f.Filters, schema)
+			continue
+		}
+
+		field, ok := schema.GetField(f.Field)
+		if !ok {
+			continue
+		}
+
+		if f.Operator == enums.ElemMatch && field.Elements != nil {
+			if children, err := f.ElemMatchFilters(); err == nil {
+				f.Value = resolveFilterPaths(types.CloneFilters(children), field.Elements)
+			}
+		}
+		if field.Type != "" {
+			f.Type = field.Type
+		}
+		f.Match = field.Match
+		f.Field = field.GetPath(f.Field)
+	}
+
+	return filters
+}
+
+func resolveFieldPath(name string, schema *types.PaginationSchema) string {
+	field, ok := schema.GetField(name)
+	if !ok {
+		return name
+	}
+	return field.GetPath(name)
+}
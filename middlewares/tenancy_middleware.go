@@ -0,0 +1,54 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/educolog9/packages/types"
+	"github.com/gin-gonic/gin"
+)
+
+// TenancyMiddleware is a middleware that scopes the request to the organization of the authenticated user.
+// It must run after AuthMiddleware, AdminMiddleware or RoleBasedAuthMiddleware, which set the user claims.
+// The tenant is stored in the request context, where the Repository and NewScopedPaginationConfig read it,
+// and in the "tenant" key of the gin context. The optional field is the document field holding the
+// organization, which defaults to types.DefaultOrganizationField.
+// Users without an organization are rejected with a 403 Forbidden; admins that need to read across
+// organizations must replace the tenant with types.NewAdminBypassTenant.
+func TenancyMiddleware(field ...string) gin.HandlerFunc {
+	organizationField := ""
+	if len(field) > 0 {
+		organizationField = field[0]
+	}
+
+	return func(c *gin.Context) {
+		value, ok := c.Get("userClaims")
+		userClaims, isClaims := value.(*types.UserClaims)
+		if !ok || !isClaims {
+			response := types.ErrorResponse{
+				Status:  http.StatusUnauthorized,
+				Message: "Unauthorized",
+				Errors:  []string{"Missing user claims"},
+			}
+			c.JSON(http.StatusUnauthorized, response)
+			c.Abort()
+			return
+		}
+
+		tenant, err := types.NewTenant(userClaims, organizationField)
+		if err != nil {
+			response := types.ErrorResponse{
+				Status:  http.StatusForbidden,
+				Message: "Forbidden",
+				Errors:  []string{err.Error()},
+			}
+			c.JSON(http.StatusForbidden, response)
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(types.WithTenant(c.Request.Context(), tenant))
+		c.Set("tenant", tenant)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MigrationFunc changes the data or the indexes of the database, e.g. renaming a field or creating an index.
+type MigrationFunc func(ctx context.Context, db *mongo.Database) error
+
+// Migration is a versioned change of the database, applied with Up and rolled back with Down.
+// Versions are applied in ascending order; a timestamp such as 20240131120000 keeps them ordered across teams.
+type Migration struct {
+	// Version identifies the migration. It must be positive and unique.
+	Version int64
+	// Description says what the migration does, e.g. "rename users.fullName to users.name".
+	Description string
+	// Up applies the migration. It must be idempotent: when the lock is lost or the process stops after Up
+	// changed the database but before the migration was recorded, Up runs again on the next run.
+	Up MigrationFunc
+	// Down reverts the migration. When nil, the migration cannot be rolled back. Like Up, it must be idempotent.
+	Down MigrationFunc
+}
+
+// MigrationStatus is the state of a migration in the database.
+type MigrationStatus struct {
+	Version     int64      `json:"version"`
+	Description string     `json:"description"`
+	Applied     bool       `json:"applied"`
+	AppliedAt   *time.Time `json:"appliedAt,omitempty"`
+	// Registered is false for migrations applied in the database but no longer registered in the Migrator.
+	Registered bool `json:"registered"`
+}
+
+// migrationRecord is the document of an applied migration in the migrations collection.
+type migrationRecord struct {
+	Version     int64     `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"appliedAt"`
+}
+
+// migrationLock is the document of the lock in the lock collection.
+type migrationLock struct {
+	ID        string    `bson:"_id"`
+	Owner     string    `bson:"owner"`
+	ExpiresAt time.Time `bson:"expiresAt"`
+}
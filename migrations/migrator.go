@@ -0,0 +1,376 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Default values of a Migrator.
+const (
+	DefaultCollection = "migrations"
+	DefaultLockTTL    = 10 * time.Minute
+)
+
+// ErrMigrationsLocked is returned when another process holds the migrations lock.
+// Replicas starting at the same time can ignore it, since the process holding the lock runs the migrations.
+var ErrMigrationsLocked = errors.New("migrations are locked by another process")
+
+// Migrator applies and rolls back the registered migrations of a database. The applied versions are
+// tracked in the Collection collection, and a lock in the "<Collection>_lock" collection ensures only
+// one process runs migrations at a time. The lock expires after LockTTL, so a crashed process does not
+// block the migrations forever, and is renewed in the background while migrations run. When it cannot be
+// renewed, the context given to the running migration is cancelled.
+type Migrator struct {
+	Database   *mongo.Database
+	Collection string
+	LockTTL    time.Duration
+	// Owner identifies the process in the lock. Defaults to the hostname and the process ID.
+	Owner string
+
+	migrations []Migration
+}
+
+// NewMigrator creates a new Migrator for the given database with the given migrations.
+func NewMigrator(db *mongo.Database, migrations ...Migration) (*Migrator, error) {
+	hostname, _ := os.Hostname()
+
+	m := &Migrator{
+		Database:   db,
+		Collection: DefaultCollection, // default value
+		LockTTL:    DefaultLockTTL,    // default value
+		Owner:      fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+	}
+
+	if err := m.Register(migrations...); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Register adds migrations to the Migrator. Versions must be positive and unique, and Up is required.
+func (m *Migrator) Register(migrations ...Migration) error {
+	for _, migration := range migrations {
+		if migration.Version <= 0 {
+			return fmt.Errorf("invalid migration version %d: must be positive", migration.Version)
+		}
+		if migration.Up == nil {
+			return fmt.Errorf("invalid migration %d: missing up function", migration.Version)
+		}
+		if _, ok := m.find(migration.Version); ok {
+			return fmt.Errorf("duplicate migration version %d", migration.Version)
+		}
+		m.migrations = append(m.migrations, migration)
+	}
+
+	sort.Slice(m.migrations, func(i, j int) bool {
+		return m.migrations[i].Version < m.migrations[j].Version
+	})
+
+	return nil
+}
+
+// Status returns the state of every registered migration, in version order, followed by the migrations
+// applied in the database that are no longer registered.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Migrator.Status")
+	defer span.Finish()
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Description: migration.Description, Registered: true}
+		if record, ok := applied[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	for _, record := range sortedRecords(applied) {
+		if _, ok := m.find(record.Version); !ok {
+			appliedAt := record.AppliedAt
+			statuses = append(statuses, MigrationStatus{Version: record.Version, Description: record.Description, Applied: true, AppliedAt: &appliedAt})
+		}
+	}
+
+	return statuses, nil
+}
+
+// Apply applies every pending migration in version order, and returns the versions applied.
+// Migrations registered with a version lower than the last applied one are applied too.
+func (m *Migrator) Apply(ctx context.Context) ([]int64, error) {
+	return m.ApplyTo(ctx, 0)
+}
+
+// ApplyTo applies the pending migrations up to the given version, included, in version order, and returns
+// the versions applied. A zero version applies every pending migration. It stops at the first migration
+// that fails, which is left pending.
+func (m *Migrator) ApplyTo(ctx context.Context, version int64) ([]int64, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Migrator.ApplyTo")
+	defer span.Finish()
+
+	var done []int64
+	err := m.withLock(ctx, func(ctx context.Context) error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if version > 0 && migration.Version > version {
+				break
+			}
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			if err := migration.Up(ctx, m.Database); err != nil {
+				return fmt.Errorf("failed to apply migration %d: %w", migration.Version, err)
+			}
+
+			// The migration is recorded even when the context was cancelled meanwhile, since it was applied.
+			record := migrationRecord{Version: migration.Version, Description: migration.Description, AppliedAt: time.Now().UTC()}
+			if _, err := m.Database.Collection(m.Collection).InsertOne(context.WithoutCancel(ctx), record); err != nil {
+				return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+			}
+
+			log.Printf("migration %d applied: %s", migration.Version, migration.Description)
+			done = append(done, migration.Version)
+
+			if err := context.Cause(ctx); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// Rollback rolls back the given number of applied migrations, from the last applied one, and returns the
+// versions rolled back.
+func (m *Migrator) Rollback(ctx context.Context, steps int) ([]int64, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Migrator.Rollback")
+	defer span.Finish()
+
+	if steps <= 0 {
+		return nil, fmt.Errorf("invalid rollback steps %d: must be positive", steps)
+	}
+
+	return m.rollback(ctx, func(index int, record migrationRecord) bool {
+		return index < steps
+	})
+}
+
+// RollbackTo rolls back every applied migration with a version greater than the given one, from the last
+// applied one, and returns the versions rolled back. A zero version rolls back every migration.
+func (m *Migrator) RollbackTo(ctx context.Context, version int64) ([]int64, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Migrator.RollbackTo")
+	defer span.Finish()
+
+	return m.rollback(ctx, func(index int, record migrationRecord) bool {
+		return record.Version > version
+	})
+}
+
+// rollback rolls back the applied migrations, in descending version order, while include returns true.
+// It stops at the first migration that is not registered, has no down function or fails.
+func (m *Migrator) rollback(ctx context.Context, include func(index int, record migrationRecord) bool) ([]int64, error) {
+	var done []int64
+	err := m.withLock(ctx, func(ctx context.Context) error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		records := sortedRecords(applied)
+		for i := len(records) - 1; i >= 0; i-- {
+			record := records[i]
+			if !include(len(records)-1-i, record) {
+				break
+			}
+
+			migration, ok := m.find(record.Version)
+			if !ok {
+				return fmt.Errorf("failed to roll back migration %d: not registered", record.Version)
+			}
+			if migration.Down == nil {
+				return fmt.Errorf("failed to roll back migration %d: missing down function", record.Version)
+			}
+
+			if err := migration.Down(ctx, m.Database); err != nil {
+				return fmt.Errorf("failed to roll back migration %d: %w", record.Version, err)
+			}
+
+			// The rollback is recorded even when the context was cancelled meanwhile, since it was applied.
+			if _, err := m.Database.Collection(m.Collection).DeleteOne(context.WithoutCancel(ctx), bson.M{"_id": record.Version}); err != nil {
+				return fmt.Errorf("failed to record the rollback of migration %d: %w", record.Version, err)
+			}
+
+			log.Printf("migration %d rolled back: %s", migration.Version, migration.Description)
+			done = append(done, migration.Version)
+
+			if err := context.Cause(ctx); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// applied returns the records of the applied migrations, keyed by version.
+func (m *Migrator) applied(ctx context.Context) (map[int64]migrationRecord, error) {
+	cursor, err := m.Database.Collection(m.Collection).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the applied migrations: %w", err)
+	}
+
+	var records []migrationRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("failed to read the applied migrations: %w", err)
+	}
+
+	applied := make(map[int64]migrationRecord, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// withLock runs fn holding the migrations lock, returning ErrMigrationsLocked when another process holds it.
+// The lock is renewed every third of its TTL while fn runs. When a renewal fails, the context given to fn is
+// cancelled with the renewal error as its cause, so fn stops before another process takes the lock.
+func (m *Migrator) withLock(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := m.acquireLock(ctx); err != nil {
+		return err
+	}
+
+	lockCtx, cancel := context.WithCancelCause(ctx)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		m.keepLock(lockCtx, cancel)
+	}()
+
+	err := fn(lockCtx)
+	if cause := context.Cause(lockCtx); err != nil && cause != nil && ctx.Err() == nil && !errors.Is(err, cause) {
+		// The lock was lost while fn ran, which is why it failed.
+		err = fmt.Errorf("%w: %w", cause, err)
+	}
+
+	cancel(nil)
+	<-stopped
+
+	// The lock is released even when the context is cancelled, so the next run does not wait for it to expire.
+	if _, releaseErr := m.lockCollection().DeleteOne(context.WithoutCancel(ctx), bson.M{"_id": m.Collection, "owner": m.Owner}); releaseErr != nil && err == nil {
+		err = fmt.Errorf("failed to release the migrations lock: %w", releaseErr)
+	}
+
+	return err
+}
+
+// acquireLock takes the lock when it is free or expired. When another process holds it, the upsert tries to
+// insert a second lock document with the same _id, which fails with a duplicate key error.
+func (m *Migrator) acquireLock(ctx context.Context) error {
+	now := time.Now().UTC()
+
+	filter := bson.M{"_id": m.Collection, "$or": bson.A{
+		bson.M{"expiresAt": bson.M{"$lt": now}},
+		bson.M{"owner": m.Owner},
+	}}
+	update := bson.M{"$set": bson.M{"owner": m.Owner, "expiresAt": now.Add(m.getLockTTL())}}
+
+	_, err := m.lockCollection().UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrMigrationsLocked
+	}
+	if err != nil {
+		return fmt.Errorf("failed to acquire the migrations lock: %w", err)
+	}
+	return nil
+}
+
+// keepLock renews the lock until the context is done, cancelling it when a renewal fails.
+func (m *Migrator) keepLock(ctx context.Context, cancel context.CancelCauseFunc) {
+	ticker := time.NewTicker(m.getLockTTL() / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.renewLock(ctx); err != nil {
+				cancel(err)
+				return
+			}
+		}
+	}
+}
+
+// renewLock extends the lock held by the process, so long runs of migrations keep it.
+func (m *Migrator) renewLock(ctx context.Context) error {
+	filter := bson.M{"_id": m.Collection, "owner": m.Owner}
+	update := bson.M{"$set": bson.M{"expiresAt": time.Now().UTC().Add(m.getLockTTL())}}
+
+	result, err := m.lockCollection().UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to renew the migrations lock: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("failed to renew the migrations lock: the lock expired and was taken by another process")
+	}
+	return nil
+}
+
+func (m *Migrator) lockCollection() *mongo.Collection {
+	return m.Database.Collection(m.Collection + "_lock")
+}
+
+func (m *Migrator) getLockTTL() time.Duration {
+	if m.LockTTL <= 0 {
+		return DefaultLockTTL
+	}
+	return m.LockTTL
+}
+
+func (m *Migrator) find(version int64) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+// sortedRecords returns the records in ascending version order.
+func sortedRecords(applied map[int64]migrationRecord) []migrationRecord {
+	records := make([]migrationRecord, 0, len(applied))
+	for _, record := range applied {
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Version < records[j].Version
+	})
+	return records
+}
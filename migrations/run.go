@@ -0,0 +1,109 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// Run runs a migrations command, so a service's main or a small CLI can expose the Migrator, e.g. with os.Args[1:]:
+//
+//	up [version]     applies the pending migrations, up to the version when given
+//	down [steps]     rolls back the last applied migrations, one when no steps are given
+//	down-to version  rolls back the migrations applied after the version
+//	status           prints the state of every migration
+//
+// The result of the command is written to out.
+func (m *Migrator) Run(ctx context.Context, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing migrations command: expected up, down, down-to or status")
+	}
+
+	command, args := args[0], args[1:]
+	switch command {
+	case "up":
+		version, err := parseRunArgument(args, 0)
+		if err != nil {
+			return err
+		}
+		versions, err := m.ApplyTo(ctx, version)
+		printVersions(out, "applied", versions)
+		return err
+	case "down":
+		steps, err := parseRunArgument(args, 1)
+		if err != nil {
+			return err
+		}
+		versions, err := m.Rollback(ctx, int(steps))
+		printVersions(out, "rolled back", versions)
+		return err
+	case "down-to":
+		if len(args) == 0 {
+			return fmt.Errorf("missing version of the down-to command")
+		}
+		version, err := parseRunArgument(args, 0)
+		if err != nil {
+			return err
+		}
+		versions, err := m.RollbackTo(ctx, version)
+		printVersions(out, "rolled back", versions)
+		return err
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		printStatuses(out, statuses)
+		return nil
+	default:
+		return fmt.Errorf("unknown migrations command %q: expected up, down, down-to or status", command)
+	}
+}
+
+// parseRunArgument reads the optional numeric argument of a command.
+func parseRunArgument(args []string, defaultValue int64) (int64, error) {
+	if len(args) == 0 {
+		return defaultValue, nil
+	}
+	if len(args) > 1 {
+		return 0, fmt.Errorf("too many arguments: %v", args)
+	}
+
+	value, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid argument %q: expected a positive number", args[0])
+	}
+	return value, nil
+}
+
+func printVersions(out io.Writer, action string, versions []int64) {
+	if len(versions) == 0 {
+		fmt.Fprintf(out, "no migrations %s\n", action)
+		return
+	}
+	for _, version := range versions {
+		fmt.Fprintf(out, "%s %d\n", action, version)
+	}
+}
+
+func printStatuses(out io.Writer, statuses []MigrationStatus) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tSTATUS\tAPPLIED AT\tDESCRIPTION")
+
+	for _, status := range statuses {
+		state, appliedAt := "pending", ""
+		if status.Applied {
+			state = "applied"
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		if !status.Registered {
+			state += " (not registered)"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", status.Version, state, appliedAt, status.Description)
+	}
+
+	w.Flush()
+}
@@ -0,0 +1,28 @@
+package types
+
+// AtlasSearchOptions configures the Atlas Search stage generated by the pipeline converters
+// when PaginationConfig.WithAtlasSearch is set.
+type AtlasSearchOptions struct {
+	// Index is the name of the Atlas Search index. Defaults to "default".
+	Index string
+	// Paths are the document paths searched by the text query. When empty, every indexed field is searched.
+	Paths []string
+	// Fuzzy enables fuzzy matching of the text query. When nil, the query must match exactly.
+	Fuzzy *AtlasSearchFuzzy
+}
+
+// AtlasSearchFuzzy configures fuzzy matching of the Atlas Search text query.
+// Zero values are left out so Atlas applies its own defaults.
+type AtlasSearchFuzzy struct {
+	MaxEdits      int
+	PrefixLength  int
+	MaxExpansions int
+}
+
+// GetIndex returns the name of the Atlas Search index, defaulting to "default".
+func (o *AtlasSearchOptions) GetIndex() string {
+	if o.Index == "" {
+		return "default"
+	}
+	return o.Index
+}
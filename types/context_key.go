@@ -9,3 +9,7 @@ const ContentLanguageKey contextKey = "contentLanguage"
 // CountryCodeKey is a context key used to store the country code.
 // It is used to retrieve the country code from the context.
 const CountryCodeKey contextKey = "countryCode"
+
+// TenantKey is a context key used to store the tenant of the request.
+// It is used to retrieve the tenant from the context.
+const TenantKey contextKey = "tenant"
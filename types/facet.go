@@ -0,0 +1,99 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/educolog9/packages/enums"
+)
+
+// Limits of the number of values returned by a terms facet.
+const (
+	DefaultFacetSize = 10
+	MaxFacetSize     = 100
+)
+
+// facetNamePattern matches the names allowed for a facet, which are used as keys of the $facet stage.
+var facetNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// facetIntervals are the units accepted by dateHistogram facets, as understood by $dateTrunc.
+var facetIntervals = map[string]bool{
+	"minute":  true,
+	"hour":    true,
+	"day":     true,
+	"week":    true,
+	"month":   true,
+	"quarter": true,
+	"year":    true,
+}
+
+// FacetRequest asks for the counts of a field among the documents matching the filters of a Pagination.
+type FacetRequest struct {
+	// Name is the key of the facet in the response. It cannot be "items" or "total".
+	Name string
+	// Field is the field whose values are counted.
+	Field string
+	// Type is the kind of facet: terms, buckets or dateHistogram.
+	Type enums.FacetType
+	// Size is the number of most frequent values of a terms facet. Defaults to DefaultFacetSize and is capped
+	// at MaxFacetSize.
+	Size int64
+	// Boundaries are the ascending lower bounds of the buckets of a buckets facet; the last one is the
+	// upper bound of the last bucket. Values outside the boundaries are counted in an "other" bucket.
+	Boundaries []float64
+	// Interval is the unit of a dateHistogram facet: minute, hour, day, week, month, quarter or year.
+	Interval string
+}
+
+// GetSize returns the number of values of a terms facet, defaulting to DefaultFacetSize and capped at MaxFacetSize.
+func (f *FacetRequest) GetSize() int64 {
+	if f.Size <= 0 {
+		return DefaultFacetSize
+	}
+	if f.Size > MaxFacetSize {
+		return MaxFacetSize
+	}
+	return f.Size
+}
+
+// Validate checks the name, field and type of the facet, and the boundaries or interval its type requires.
+// It returns the same error the databases converters return for the facet.
+func (f *FacetRequest) Validate() error {
+	if !facetNamePattern.MatchString(f.Name) || f.Name == "items" || f.Name == "total" {
+		return fmt.Errorf("invalid facet name %q", f.Name)
+	}
+	if f.Field == "" || strings.HasPrefix(f.Field, "$") {
+		return fmt.Errorf("invalid field %q for facet %s", f.Field, f.Name)
+	}
+
+	switch f.Type {
+	case enums.Terms:
+		return nil
+	case enums.Buckets:
+		if len(f.Boundaries) < 2 || !sort.Float64sAreSorted(f.Boundaries) {
+			return fmt.Errorf("facet %s requires at least two ascending boundaries", f.Name)
+		}
+		for i := 1; i < len(f.Boundaries); i++ {
+			if f.Boundaries[i] == f.Boundaries[i-1] {
+				return fmt.Errorf("facet %s requires at least two ascending boundaries", f.Name)
+			}
+		}
+		return nil
+	case enums.DateHistogram:
+		if !facetIntervals[f.Interval] {
+			return fmt.Errorf("invalid interval %q for facet %s", f.Interval, f.Name)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid type %q for facet %s", f.Type, f.Name)
+	}
+}
+
+// FacetBucket is the count of a value, bucket or date interval of a facet.
+// @name FacetBucket
+type FacetBucket struct {
+	Value interface{} `bson:"_id" json:"value"`   // The value, the lower bound of the bucket or the start of the interval.
+	Count int64       `bson:"count" json:"count"` // The number of documents in the bucket.
+}
@@ -0,0 +1,174 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/educolog9/packages/enums"
+)
+
+// Validate checks that the value of the filter has the shape its operator expects, coerced to the
+// filter type when it has one, and, for groups and elemMatch filters, that their nested filters are valid.
+// It returns the same error the databases converters return for the filter.
+func (f *Filter) Validate() error {
+	if f.IsGroup() {
+		return f.validateGroup()
+	}
+
+	switch f.Operator {
+	case enums.Equal, enums.NotEqual, enums.GreaterThan, enums.GreaterThanOrEqual, enums.LessThan, enums.LessThanOrEqual:
+		_, err := f.ResolveValue()
+		return err
+	case enums.In, enums.NotIn:
+		_, err := f.ResolveInValues()
+		return err
+	case enums.Like, enums.NotLike:
+		value, err := f.TextValue()
+		if err != nil {
+			return err
+		}
+		if options := f.GetMatch(); options.Raw {
+			if err := options.ValidateRegex(value); err != nil {
+				return fmt.Errorf("invalid value for '%s' operator: %w", f.Operator, err)
+			}
+		}
+		return nil
+	case enums.StartsWith, enums.EndsWith, enums.Contains:
+		_, err := f.TextValue()
+		return err
+	case enums.Exists:
+		if _, err := FilterValueToBool(f.Value); err != nil {
+			return InvalidFilterValueError(f.Operator, "a boolean")
+		}
+		return nil
+	case enums.Between:
+		bounds, err := f.ResolveList()
+		if err != nil {
+			return err
+		}
+		if len(bounds) != 2 {
+			return InvalidFilterValueError(f.Operator, "a list with a lower and an upper bound")
+		}
+		return nil
+	case enums.All:
+		values, err := f.ResolveList()
+		if err != nil {
+			return err
+		}
+		if len(values) == 0 {
+			return InvalidFilterValueError(f.Operator, "a non-empty list")
+		}
+		return nil
+	case enums.Size:
+		size, err := FilterValueToInt(f.Value)
+		if err != nil || size < 0 {
+			return InvalidFilterValueError(f.Operator, "a non-negative integer")
+		}
+		return nil
+	case enums.ElemMatch:
+		return f.validateElemMatch()
+	case enums.Near:
+		_, err := ParseGeoNear(f.Value)
+		return wrapGeoValueError(f.Operator, err)
+	case enums.WithinRadius:
+		_, _, err := ParseGeoCircle(f.Value)
+		return wrapGeoValueError(f.Operator, err)
+	case enums.WithinBox:
+		_, _, err := ParseGeoBox(f.Value)
+		return wrapGeoValueError(f.Operator, err)
+	case enums.WithinPolygon:
+		_, err := ParseGeoPolygon(f.Value)
+		return wrapGeoValueError(f.Operator, err)
+	default:
+		return fmt.Errorf("unsupported operator %s", f.Operator)
+	}
+}
+
+// validateGroup checks that a group is not empty, is an and, or or not group and holds no near filter.
+func (f *Filter) validateGroup() error {
+	if len(f.Filters) == 0 {
+		return fmt.Errorf("empty '%s' filter group", f.Group)
+	}
+
+	if ContainsGeoNearFilter(f.Filters) {
+		return fmt.Errorf("'near' filters cannot be nested in filter groups")
+	}
+
+	switch f.Group {
+	case enums.And, enums.Or, enums.Not:
+	default:
+		return fmt.Errorf("unsupported filter group %s", f.Group)
+	}
+
+	for i := range f.Filters {
+		if err := f.Filters[i].Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateElemMatch checks the filters of an elemMatch filter. Filters with a field are matched against the
+// fields of each array element and filters without a field against the element itself, so both kinds cannot
+// be mixed, and an operator cannot be repeated on the element.
+func (f *Filter) validateElemMatch() error {
+	filters, err := f.ElemMatchFilters()
+	if err != nil {
+		return err
+	}
+	if ContainsGeoNearFilter(filters) {
+		return fmt.Errorf("'near' filters cannot be nested in elemMatch filters")
+	}
+
+	hasFieldFilters := false
+	elementOperators := map[enums.Operator]bool{}
+	for i := range filters {
+		child := &filters[i]
+		if err := child.Validate(); err != nil {
+			return err
+		}
+
+		if child.IsGroup() || child.Field != "" {
+			hasFieldFilters = true
+			continue
+		}
+		if elementOperators[child.Operator] {
+			return fmt.Errorf("invalid value for 'elemMatch' operator: operator %s is repeated", child.Operator)
+		}
+		elementOperators[child.Operator] = true
+	}
+
+	if hasFieldFilters && len(elementOperators) > 0 {
+		return fmt.Errorf("invalid value for 'elemMatch' operator: element and field conditions cannot be mixed")
+	}
+	return nil
+}
+
+func wrapGeoValueError(operator enums.Operator, err error) error {
+	if err != nil {
+		return fmt.Errorf("invalid value for '%s' operator: %w", operator, err)
+	}
+	return nil
+}
+
+// ContainsGeoNearFilter checks if any filter of the tree, at any depth of its groups and elemMatch filters,
+// is a near filter, which can only be used at the top level.
+func ContainsGeoNearFilter(filters []Filter) bool {
+	for _, f := range filters {
+		if f.IsGroup() {
+			if ContainsGeoNearFilter(f.Filters) {
+				return true
+			}
+			continue
+		}
+
+		switch f.Operator {
+		case enums.Near:
+			return true
+		case enums.ElemMatch:
+			if children, err := filterValueToFilters(f.Value); err == nil && ContainsGeoNearFilter(children) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,336 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/educolog9/packages/enums"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InvalidFilterValueError returns the error used when a filter value does not fit its operator.
+func InvalidFilterValueError(operator enums.Operator, expected string) error {
+	return fmt.Errorf("invalid value for '%s' operator: expected %s", operator, expected)
+}
+
+// FilterValueToBool reads a boolean filter value, accepting "true" and "false" strings.
+func FilterValueToBool(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		return strconv.ParseBool(strings.TrimSpace(v))
+	default:
+		return false, fmt.Errorf("invalid boolean value %v", value)
+	}
+}
+
+// FilterValueToInt reads an integer filter value.
+// JSON numbers are decoded as float64, so integral floats and numeric strings are accepted.
+func FilterValueToInt(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, fmt.Errorf("invalid integer value %v", value)
+		}
+		return int64(v), nil
+	case json.Number:
+		return v.Int64()
+	case string:
+		return strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+	default:
+		return 0, fmt.Errorf("invalid integer value %v", value)
+	}
+}
+
+// FilterValueToList reads a list filter value.
+// Strings are read in the comma-separated form documented in enums/operators.go, e.g. "18,19,20".
+func FilterValueToList(value interface{}) ([]interface{}, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		return v, nil
+	case []string:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = item
+		}
+		return list, nil
+	case string:
+		parts := strings.Split(v, ",")
+		list := make([]interface{}, len(parts))
+		for i, part := range parts {
+			list[i] = strings.TrimSpace(part)
+		}
+		return list, nil
+	default:
+		return nil, fmt.Errorf("invalid list value %v", value)
+	}
+}
+
+// ResolveValue returns the value of a scalar filter coerced to the filter type.
+// Filters without a type keep the historical behavior: strings that are valid ObjectID hex
+// strings are converted to ObjectIDs.
+func (f *Filter) ResolveValue() (interface{}, error) {
+	if f.Type == "" {
+		if str, ok := f.Value.(string); ok {
+			if id, err := primitive.ObjectIDFromHex(str); err == nil {
+				return id, nil
+			}
+		}
+		return f.Value, nil
+	}
+
+	value, err := CoerceFilterValue(f.Value, f.Type)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for field %s: %w", f.Field, err)
+	}
+	return value, nil
+}
+
+// ResolveList returns the value of a list filter with each element coerced to the filter type.
+func (f *Filter) ResolveList() ([]interface{}, error) {
+	list, err := FilterValueToList(f.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.Type == "" {
+		return list, nil
+	}
+
+	coerced := make([]interface{}, len(list))
+	for i, item := range list {
+		value, err := CoerceFilterValue(item, f.Type)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for field %s at position %d: %w", f.Field, i, err)
+		}
+		coerced[i] = value
+	}
+
+	return coerced, nil
+}
+
+// ResolveInValues returns the value of an in or notIn filter as a list with each element coerced to the
+// filter type. A single scalar value is read as a one-element list.
+func (f *Filter) ResolveInValues() ([]interface{}, error) {
+	switch f.Value.(type) {
+	case []interface{}, []string, string:
+		return f.ResolveList()
+	case nil, map[string]interface{}:
+		return nil, InvalidFilterValueError(f.Operator, "a list")
+	}
+
+	scalar := *f
+	scalar.Value = []interface{}{f.Value}
+	return scalar.ResolveList()
+}
+
+// CoerceFilterValue converts a filter value to the given type.
+// Values already of the right type are returned as they are; strings are parsed.
+func CoerceFilterValue(value interface{}, valueType enums.CustomTypes) (interface{}, error) {
+	switch valueType {
+	case enums.Integer:
+		integer, err := FilterValueToInt(value)
+		if err != nil {
+			return nil, fmt.Errorf("%v is not a valid integer", value)
+		}
+		return integer, nil
+	case enums.Float:
+		float, err := FilterValueToFloat(value)
+		if err != nil {
+			return nil, fmt.Errorf("%v is not a valid float", value)
+		}
+		return float, nil
+	case enums.Boolean:
+		boolean, err := FilterValueToBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%v is not a valid boolean", value)
+		}
+		return boolean, nil
+	case enums.String:
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		case bool:
+			return strconv.FormatBool(v), nil
+		case json.Number:
+			return v.String(), nil
+		default:
+			return nil, fmt.Errorf("%v is not a valid string", value)
+		}
+	case enums.ObjectID:
+		switch v := value.(type) {
+		case primitive.ObjectID:
+			return v, nil
+		case string:
+			id, err := primitive.ObjectIDFromHex(v)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a valid ObjectID", v)
+			}
+			return id, nil
+		default:
+			return nil, fmt.Errorf("%v is not a valid ObjectID", value)
+		}
+	case enums.Date, enums.DateTime:
+		return filterValueToDateTime(value, valueType)
+	default:
+		return nil, fmt.Errorf("unsupported filter type %s", valueType)
+	}
+}
+
+// FilterValueToFloat reads a float filter value.
+func FilterValueToFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case json.Number:
+		return v.Float64()
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(v), 64)
+	default:
+		return 0, fmt.Errorf("invalid float value %v", value)
+	}
+}
+
+// dateLayouts are the layouts accepted for date filter values, besides RFC 3339.
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+}
+
+// filterValueToDateTime reads a date or datetime filter value as a BSON date, resolving relative
+// expressions at the current time in UTC. See FilterValueToDateTime.
+func filterValueToDateTime(value interface{}, valueType enums.CustomTypes) (primitive.DateTime, error) {
+	return FilterValueToDateTime(value, valueType, time.Now().UTC())
+}
+
+// FilterValueToDateTime reads a date or datetime filter value as a BSON date.
+// Strings are parsed as ISO 8601, with dates without a time zone read in the time zone of now, or as
+// relative expressions such as "now-7d" or "startOfMonth" resolved at now (see parseRelativeTime).
+// Numbers are read as milliseconds since the Unix epoch, and BSON dates are kept as they are, since they
+// were already resolved. Date values are truncated to the start of their day in the time zone of now, and
+// stored as that day at midnight UTC, except end-of-period expressions such as "endOfDay", which keep the
+// last millisecond of that day so `lte endOfDay` still includes it.
+func FilterValueToDateTime(value interface{}, valueType enums.CustomTypes, now time.Time) (primitive.DateTime, error) {
+	var t time.Time
+	endOfPeriod := false
+
+	switch v := value.(type) {
+	case primitive.DateTime:
+		return v, nil
+	case time.Time:
+		t = v
+	case float64, int, int64, json.Number:
+		ms, err := FilterValueToInt(v)
+		if err != nil {
+			return 0, fmt.Errorf("%v is not a valid %s", value, valueType)
+		}
+		t = time.UnixMilli(ms)
+	case string:
+		str := strings.TrimSpace(v)
+		if isRelativeTime(str) {
+			parsed, err := parseRelativeTime(str, now)
+			if err != nil {
+				return 0, err
+			}
+			t = parsed
+			endOfPeriod = strings.HasPrefix(str, "endOf")
+			break
+		}
+		parsed, err := time.Parse(time.RFC3339Nano, str)
+		if err != nil {
+			for _, layout := range dateLayouts {
+				if parsed, err = time.ParseInLocation(layout, str, now.Location()); err == nil {
+					break
+				}
+			}
+		}
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a valid %s", v, valueType)
+		}
+		t = parsed
+	default:
+		return 0, fmt.Errorf("%v is not a valid %s", value, valueType)
+	}
+
+	if valueType == enums.Date {
+		year, month, day := t.In(now.Location()).Date()
+		t = time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		if endOfPeriod {
+			t = t.AddDate(0, 0, 1).Add(-time.Millisecond)
+		}
+	}
+
+	return primitive.NewDateTimeFromTime(t), nil
+}
+
+// ElemMatchFilters returns the filters of the value of an elemMatch filter, given as a list of filters
+// or as the generic JSON decoded from the `p` parameter.
+func (f *Filter) ElemMatchFilters() ([]Filter, error) {
+	filters, err := filterValueToFilters(f.Value)
+	if err != nil || len(filters) == 0 {
+		return nil, InvalidFilterValueError(f.Operator, "a non-empty list of filters")
+	}
+	return filters, nil
+}
+
+// TextValue returns the value of a text filter, checking it is a non-empty string
+// within the maximum length of the filter's match options.
+func (f *Filter) TextValue() (string, error) {
+	value, ok := f.Value.(string)
+	if !ok || value == "" {
+		return "", InvalidFilterValueError(f.Operator, "a non-empty string")
+	}
+
+	options := f.GetMatch()
+	if utf8.RuneCountInString(value) > options.GetMaxLength() {
+		return "", InvalidFilterValueError(f.Operator, fmt.Sprintf("at most %d characters", options.GetMaxLength()))
+	}
+
+	return value, nil
+}
+
+func filterValueToFilters(value interface{}) ([]Filter, error) {
+	switch v := value.(type) {
+	case []Filter:
+		return v, nil
+	case Filter:
+		return []Filter{v}, nil
+	case map[string]interface{}:
+		value = []interface{}{v}
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var filters []Filter
+	if err := json.Unmarshal(data, &filters); err != nil {
+		return nil, err
+	}
+
+	return filters, nil
+}
@@ -0,0 +1,221 @@
+package types
+
+import (
+	"fmt"
+	"math"
+)
+
+// GeoPoint represents a geographic location.
+// @name GeoPoint
+// @field:lat "The latitude of the location, between -90 and 90."
+// @field:lng "The longitude of the location, between -180 and 180."
+type GeoPoint struct {
+	Latitude  float64 `json:"lat" validate:"latitude"`  // The latitude of the location, between -90 and 90.
+	Longitude float64 `json:"lng" validate:"longitude"` // The longitude of the location, between -180 and 180.
+}
+
+// IsValid checks if the latitude and longitude of the point are valid.
+func (p GeoPoint) IsValid() bool {
+	return IsValidLatitude(p.Latitude) && IsValidLongitude(p.Longitude)
+}
+
+// Coordinates returns the point as GeoJSON coordinates, which are in [longitude, latitude] order.
+func (p GeoPoint) Coordinates() []float64 {
+	return []float64{p.Longitude, p.Latitude}
+}
+
+// IsValidLatitude checks if the latitude is between -90 and 90.
+func IsValidLatitude(lat float64) bool {
+	return lat >= -90 && lat <= 90
+}
+
+// IsValidLongitude checks if the longitude is between -180 and 180.
+func IsValidLongitude(lon float64) bool {
+	return lon >= -180 && lon <= 180
+}
+
+// GeoNear is a parsed near filter value.
+type GeoNear struct {
+	Point       GeoPoint
+	MaxDistance float64
+	MinDistance float64
+}
+
+// ParseGeoNear reads a near filter value: a point with optional maxDistance and minDistance in meters.
+func ParseGeoNear(value interface{}) (*GeoNear, error) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a point with optional maxDistance and minDistance")
+	}
+
+	point, err := ParseGeoPoint(object)
+	if err != nil {
+		return nil, err
+	}
+
+	near := &GeoNear{Point: point}
+	if raw, ok := object["maxDistance"]; ok {
+		if near.MaxDistance, err = FilterValueToFloat(raw); err != nil || !isFiniteGeoDistance(near.MaxDistance) || near.MaxDistance < 0 {
+			return nil, fmt.Errorf("maxDistance must be a positive number of meters")
+		}
+	}
+	if raw, ok := object["minDistance"]; ok {
+		if near.MinDistance, err = FilterValueToFloat(raw); err != nil || !isFiniteGeoDistance(near.MinDistance) || near.MinDistance < 0 {
+			return nil, fmt.Errorf("minDistance must be a positive number of meters")
+		}
+	}
+
+	return near, nil
+}
+
+// ParseGeoPoint reads a point given as {"lat":..,"lng":..}, as a GeoJSON Point,
+// or as a [longitude, latitude] pair, and validates its coordinates.
+func ParseGeoPoint(value interface{}) (GeoPoint, error) {
+	var point GeoPoint
+
+	switch v := value.(type) {
+	case GeoPoint:
+		point = v
+	case map[string]interface{}:
+		if coordinates, ok := v["coordinates"]; ok {
+			if v["type"] != "Point" {
+				return point, fmt.Errorf("expected a GeoJSON Point")
+			}
+			return ParseGeoPoint(coordinates)
+		}
+		lat, err := FilterValueToFloat(v["lat"])
+		if err != nil {
+			return point, fmt.Errorf("expected a point with lat and lng")
+		}
+		lng, err := FilterValueToFloat(v["lng"])
+		if err != nil {
+			return point, fmt.Errorf("expected a point with lat and lng")
+		}
+		point = GeoPoint{Latitude: lat, Longitude: lng}
+	case []interface{}:
+		if len(v) != 2 {
+			return point, fmt.Errorf("expected a [longitude, latitude] pair")
+		}
+		lng, err := FilterValueToFloat(v[0])
+		if err != nil {
+			return point, fmt.Errorf("expected a [longitude, latitude] pair")
+		}
+		lat, err := FilterValueToFloat(v[1])
+		if err != nil {
+			return point, fmt.Errorf("expected a [longitude, latitude] pair")
+		}
+		point = GeoPoint{Latitude: lat, Longitude: lng}
+	default:
+		return point, fmt.Errorf("expected a point")
+	}
+
+	if !IsValidLatitude(point.Latitude) {
+		return point, fmt.Errorf("%v is not a valid latitude", point.Latitude)
+	}
+	if !IsValidLongitude(point.Longitude) {
+		return point, fmt.Errorf("%v is not a valid longitude", point.Longitude)
+	}
+
+	return point, nil
+}
+
+// ParseGeoCircle reads a withinRadius filter value: a point with a radius in meters.
+func ParseGeoCircle(value interface{}) (GeoPoint, float64, error) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return GeoPoint{}, 0, fmt.Errorf("expected a point with a radius")
+	}
+
+	point, err := ParseGeoPoint(object)
+	if err != nil {
+		return GeoPoint{}, 0, err
+	}
+
+	radius, err := FilterValueToFloat(object["radius"])
+	if err != nil || !isFiniteGeoDistance(radius) || radius <= 0 {
+		return GeoPoint{}, 0, fmt.Errorf("expected a positive radius in meters")
+	}
+
+	return point, radius, nil
+}
+
+// ParseGeoBox reads a box given as {"bottomLeft":point,"topRight":point} or as a pair of points.
+// A bottomLeft east of topRight is a box crossing the antimeridian, e.g. from 170 to -170 longitude.
+func ParseGeoBox(value interface{}) (GeoPoint, GeoPoint, error) {
+	var corners []interface{}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		corners = []interface{}{v["bottomLeft"], v["topRight"]}
+	case []interface{}:
+		corners = v
+	}
+
+	if len(corners) != 2 {
+		return GeoPoint{}, GeoPoint{}, fmt.Errorf("expected a bottomLeft and a topRight point")
+	}
+
+	bottomLeft, err := ParseGeoPoint(corners[0])
+	if err != nil {
+		return GeoPoint{}, GeoPoint{}, err
+	}
+	topRight, err := ParseGeoPoint(corners[1])
+	if err != nil {
+		return GeoPoint{}, GeoPoint{}, err
+	}
+
+	if bottomLeft.Latitude >= topRight.Latitude {
+		return GeoPoint{}, GeoPoint{}, fmt.Errorf("bottomLeft must be below topRight")
+	}
+	if bottomLeft.Longitude == topRight.Longitude || (bottomLeft.Longitude == 180 && topRight.Longitude == -180) {
+		return GeoPoint{}, GeoPoint{}, fmt.Errorf("bottomLeft and topRight must have different longitudes")
+	}
+
+	return bottomLeft, topRight, nil
+}
+
+// ParseGeoPolygon reads a polygon given as a GeoJSON Polygon (only its exterior ring is used)
+// or as a list of points, and returns its ring closed as GeoJSON requires.
+func ParseGeoPolygon(value interface{}) ([][]float64, error) {
+	points, ok := value.([]interface{})
+	if object, isObject := value.(map[string]interface{}); isObject {
+		if object["type"] != "Polygon" {
+			return nil, fmt.Errorf("expected a GeoJSON Polygon")
+		}
+		rings, isList := object["coordinates"].([]interface{})
+		if !isList || len(rings) == 0 {
+			return nil, fmt.Errorf("expected a GeoJSON Polygon")
+		}
+		points, ok = rings[0].([]interface{})
+	}
+	if !ok {
+		return nil, fmt.Errorf("expected a list of points")
+	}
+
+	var ring [][]float64
+	for _, value := range points {
+		point, err := ParseGeoPoint(value)
+		if err != nil {
+			return nil, err
+		}
+		ring = append(ring, point.Coordinates())
+	}
+
+	if len(ring) > 0 {
+		first, last := ring[0], ring[len(ring)-1]
+		if first[0] != last[0] || first[1] != last[1] {
+			ring = append(ring, first)
+		}
+	}
+
+	if len(ring) < 4 {
+		return nil, fmt.Errorf("expected at least 3 distinct points")
+	}
+
+	return ring, nil
+}
+
+// isFiniteGeoDistance checks if a distance is a number, rejecting the NaN and infinite values ParseFloat accepts.
+func isFiniteGeoDistance(distance float64) bool {
+	return !math.IsNaN(distance) && !math.IsInf(distance, 0)
+}
@@ -0,0 +1,39 @@
+package types
+
+// Default limits applied to like and notLike filter values.
+const (
+	DefaultMatchMaxLength      = 100
+	DefaultMatchMaxQuantifiers = 5
+)
+
+// MatchOptions configures how like and notLike filters match text on a field.
+// By default the value is matched as a literal, case-sensitive substring.
+type MatchOptions struct {
+	// CaseInsensitive matches regardless of letter case.
+	CaseInsensitive bool
+	// AccentInsensitive matches Spanish accented letters and their base letter alike (e.g. "jose" matches "José").
+	AccentInsensitive bool
+	// Raw treats the value as a regular expression instead of a literal substring.
+	Raw bool
+	// MaxLength limits the length of the value. Defaults to DefaultMatchMaxLength.
+	MaxLength int
+	// MaxQuantifiers limits the number of repetition operators in a raw regular expression.
+	// Defaults to DefaultMatchMaxQuantifiers.
+	MaxQuantifiers int
+}
+
+// GetMaxLength returns the maximum length of the value, defaulting to DefaultMatchMaxLength.
+func (m *MatchOptions) GetMaxLength() int {
+	if m.MaxLength <= 0 {
+		return DefaultMatchMaxLength
+	}
+	return m.MaxLength
+}
+
+// GetMaxQuantifiers returns the maximum number of repetition operators, defaulting to DefaultMatchMaxQuantifiers.
+func (m *MatchOptions) GetMaxQuantifiers() int {
+	if m.MaxQuantifiers <= 0 {
+		return DefaultMatchMaxQuantifiers
+	}
+	return m.MaxQuantifiers
+}
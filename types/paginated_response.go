@@ -0,0 +1,42 @@
+package types
+
+// PaginatedResponse represents a page of items returned by a list endpoint.
+// @name PaginatedResponse
+// @field:items "The items of the page."
+// @field:total "The total number of items matching the filters."
+// @field:offset "The offset of the page."
+// @field:limit "The maximum number of items of the page."
+// @field:hasMore "Whether there are more items after this page."
+// @field:next "The cursor of the next page, when using cursor pagination."
+// @field:prev "The cursor of the previous page, when using cursor pagination."
+// @field:facets "The counts of the requested facets, keyed by facet name."
+// @field:nextUrl "The URL of the next page, when set with SetPaginationLinks."
+// @field:prevUrl "The URL of the previous page, when set with SetPaginationLinks."
+type PaginatedResponse[T any] struct {
+	Items   []T                      `json:"items"`             // The items of the page.
+	Total   int64                    `json:"total"`             // The total number of items matching the filters.
+	Offset  int64                    `json:"offset"`            // The offset of the page.
+	Limit   int64                    `json:"limit"`             // The maximum number of items of the page.
+	HasMore bool                     `json:"hasMore"`           // Whether there are more items after this page.
+	Next    string                   `json:"next,omitempty"`    // The cursor of the next page, when using cursor pagination.
+	Prev    string                   `json:"prev,omitempty"`    // The cursor of the previous page, when using cursor pagination.
+	Facets  map[string][]FacetBucket `json:"facets,omitempty"`  // The counts of the requested facets, keyed by facet name.
+	NextURL string                   `json:"nextUrl,omitempty"` // The path and query of the next page, when set with SetPaginationLinks.
+	PrevURL string                   `json:"prevUrl,omitempty"` // The path and query of the previous page, when set with SetPaginationLinks.
+}
+
+// NewPaginatedResponse creates a new PaginatedResponse for an offset paginated page.
+// Items is never nil so the response always serializes as a JSON array.
+func NewPaginatedResponse[T any](items []T, total int64, pagination *Pagination) *PaginatedResponse[T] {
+	if items == nil {
+		items = []T{}
+	}
+
+	return &PaginatedResponse[T]{
+		Items:   items,
+		Total:   total,
+		Offset:  pagination.GetOffset(),
+		Limit:   pagination.GetLimit(),
+		HasMore: pagination.GetOffset()+int64(len(items)) < total,
+	}
+}
@@ -1,17 +1,73 @@
 package types
 
-import "github.com/educolog9/packages/enums"
+import (
+	"context"
+	"time"
 
+	"github.com/educolog9/packages/countries"
+	"github.com/educolog9/packages/enums"
+)
+
+// Filter is either a condition on a single field or, when Group is set, a logical group of nested filters.
+// Groups can be nested to build trees such as "age >= 18 AND (country = do OR country = us)".
+// Type is an optional hint used to coerce Value (and each element of list values) before querying.
+// Match configures like and notLike filters; it is set from the PaginationSchema and never read from the client.
 type Filter struct {
 	Field    string
 	Operator enums.Operator
 	Value    interface{}
+	Type     enums.CustomTypes
+	Group    enums.LogicalOperator
+	Filters  []Filter
+	Match    *MatchOptions `json:"-"`
+}
+
+// GetMatch returns the match options of the filter, or the defaults when none are set.
+func (f *Filter) GetMatch() *MatchOptions {
+	if f.Match == nil {
+		return &MatchOptions{}
+	}
+	return f.Match
+}
+
+// IsGroup reports whether the filter is a logical group of nested filters.
+func (f *Filter) IsGroup() bool {
+	return f.Group != ""
+}
+
+// SortField is a single field of a multi-field sort, applied in the order it appears in Pagination.Sorts.
+type SortField struct {
+	Field string
+	Order enums.SortOrder
+}
+
+// GetOrder returns the sort order of the field, defaulting to ascending.
+func (s *SortField) GetOrder() enums.SortOrder {
+	if s.Order == "" {
+		return enums.Asc
+	}
+	return s.Order
 }
 
 type PaginationConfig struct {
 	Pagination      *Pagination
 	WithLimit       bool
 	WithAtlasSearch bool
+	// WithCursor enables keyset pagination even when the request carries no
+	// cursor yet, so the first page is sorted the same way as the following ones.
+	WithCursor bool
+	// AtlasSearch configures the $search stage used when WithAtlasSearch is set.
+	AtlasSearch *AtlasSearchOptions
+	// Tenant restricts every query to an organization. When nil, the queries are not scoped.
+	Tenant *Tenant
+	// Location is the time zone relative date values such as startOfDay are resolved in. Defaults to UTC.
+	Location *time.Location
+	// Language is the language of the request, e.g. "es". When set, the $text search uses its stemming
+	// and stop words, and the Mongo queries sort and compare strings with its collation when
+	// TextSearch enables it.
+	Language string
+	// TextSearch configures the case and diacritic sensitivity of the $text search and the collation.
+	TextSearch *TextSearchOptions
 }
 
 func NewPaginationConfig(pagination *Pagination) *PaginationConfig {
@@ -19,18 +75,81 @@ func NewPaginationConfig(pagination *Pagination) *PaginationConfig {
 		Pagination:      pagination,
 		WithLimit:       true,  // default value
 		WithAtlasSearch: false, // default value
+		WithCursor:      false, // default value
+		AtlasSearch:     nil,   // default value
+		Tenant:          nil,   // default value
+		Location:        nil,   // default value
+		Language:        "",    // default value
+		TextSearch:      nil,   // default value
 	}
 }
 
+// NewScopedPaginationConfig creates a new PaginationConfig scoped to the tenant of the context,
+// as set by TenancyMiddleware, with its dates resolved in the time zone of the country of the context,
+// as set by CountryMiddleware, and its text search and collation in the language of the context,
+// as set by LanguageMiddleware.
+func NewScopedPaginationConfig(ctx context.Context, pagination *Pagination) *PaginationConfig {
+	config := NewPaginationConfig(pagination)
+	config.Tenant = TenantFromContext(ctx)
+	if country, ok := ctx.Value(CountryCodeKey).(string); ok {
+		config.Location = countries.Country(country).Location()
+	}
+	if language, ok := ctx.Value(ContentLanguageKey).(string); ok {
+		config.Language = language
+	}
+	return config
+}
+
+// GetLocation returns the time zone of relative date values, defaulting to UTC.
+func (c *PaginationConfig) GetLocation() *time.Location {
+	if c.Location == nil {
+		return time.UTC
+	}
+	return c.Location
+}
+
+// GetTextSearch returns the text search options, or the defaults when none are set.
+func (c *PaginationConfig) GetTextSearch() *TextSearchOptions {
+	if c.TextSearch == nil {
+		return &TextSearchOptions{}
+	}
+	return c.TextSearch
+}
+
+// GetAtlasSearch returns the Atlas Search options, or the defaults when none are set.
+func (c *PaginationConfig) GetAtlasSearch() *AtlasSearchOptions {
+	if c.AtlasSearch == nil {
+		return &AtlasSearchOptions{}
+	}
+	return c.AtlasSearch
+}
+
+// UsesAtlasSearch reports whether the pipeline should start with an Atlas Search stage.
+func (c *PaginationConfig) UsesAtlasSearch() bool {
+	return c.WithAtlasSearch && c.Pagination.GetSearch() != ""
+}
+
+// UsesCursor reports whether the query should be paginated by cursor instead of by offset.
+func (c *PaginationConfig) UsesCursor() bool {
+	return c.WithCursor || c.Pagination.HasCursor()
+}
+
 type Pagination struct {
 	Offset  int64
 	Limit   int64
 	Search  string
 	Sort    string
 	Order   enums.SortOrder
+	Sorts   []SortField
 	Next    string
 	Prev    string
 	Filters []Filter
+	// Fields lists the only fields to return. It cannot be combined with ExcludeFields.
+	Fields []string
+	// ExcludeFields lists the fields to leave out of the returned documents.
+	ExcludeFields []string
+	// Facets lists the counts to return alongside the page, computed over every document matching the filters.
+	Facets []FacetRequest
 }
 
 func (p *Pagination) GetOffset() int64 {
@@ -39,7 +158,7 @@ func (p *Pagination) GetOffset() int64 {
 
 func (p *Pagination) GetLimit() int64 {
 	if p.Limit == 0 {
-		return 10
+		return DefaultPaginationLimit
 	}
 	return p.Limit
 }
@@ -59,6 +178,18 @@ func (p *Pagination) GetOrder() enums.SortOrder {
 	return p.Order
 }
 
+// GetSorts returns the ordered list of sort fields.
+// When Sorts is empty, the legacy Sort/Order pair is returned as a single field.
+func (p *Pagination) GetSorts() []SortField {
+	if len(p.Sorts) > 0 {
+		return p.Sorts
+	}
+	if p.Sort != "" {
+		return []SortField{{Field: p.Sort, Order: p.GetOrder()}}
+	}
+	return nil
+}
+
 func (p *Pagination) GetNext() string {
 	return p.Next
 }
@@ -67,6 +198,57 @@ func (p *Pagination) GetPrev() string {
 	return p.Prev
 }
 
+// HasCursor reports whether the pagination carries a next or prev cursor.
+func (p *Pagination) HasCursor() bool {
+	return p.Next != "" || p.Prev != ""
+}
+
 func (p *Pagination) GetFilters() []Filter {
 	return p.Filters
 }
+
+func (p *Pagination) GetFields() []string {
+	return p.Fields
+}
+
+func (p *Pagination) GetExcludeFields() []string {
+	return p.ExcludeFields
+}
+
+func (p *Pagination) GetFacets() []FacetRequest {
+	return p.Facets
+}
+
+// Clone returns a deep copy of the pagination, so it can be rewritten while the original is kept
+// as the client sent it.
+func (p *Pagination) Clone() *Pagination {
+	clone := *p
+	clone.Sorts = append([]SortField(nil), p.Sorts...)
+	clone.Filters = CloneFilters(p.Filters)
+	clone.Fields = append([]string(nil), p.Fields...)
+	clone.ExcludeFields = append([]string(nil), p.ExcludeFields...)
+	clone.Facets = append([]FacetRequest(nil), p.Facets...)
+	return &clone
+}
+
+// CloneFilters returns a deep copy of a filter tree, including the nested filters of elemMatch values.
+func CloneFilters(filters []Filter) []Filter {
+	if filters == nil {
+		return nil
+	}
+
+	clone := make([]Filter, len(filters))
+	for i, f := range filters {
+		f.Filters = CloneFilters(f.Filters)
+		if children, ok := f.Value.([]Filter); ok {
+			f.Value = CloneFilters(children)
+		}
+		clone[i] = f
+	}
+	return clone
+}
+
+// HasProjection reports whether the pagination requests a subset of the document fields.
+func (p *Pagination) HasProjection() bool {
+	return len(p.Fields) > 0 || len(p.ExcludeFields) > 0
+}
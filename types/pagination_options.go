@@ -0,0 +1,30 @@
+package types
+
+// DefaultPaginationLimit is the number of items of a page when the client does not send a limit.
+const DefaultPaginationLimit = 10
+
+// PaginationOptions configures how an endpoint accepts a Pagination from its clients.
+type PaginationOptions struct {
+	// Schema is the allowlist of fields the endpoint accepts. When nil, the fields are not validated.
+	Schema *PaginationSchema
+	// DefaultLimit is the limit used when the client does not send one. Defaults to DefaultPaginationLimit.
+	DefaultLimit int64
+	// MaxLimit is the greatest limit a client may request. When zero, the limit is not capped.
+	MaxLimit int64
+	// MaxOffset is the greatest offset a client may request. When zero, the offset is not capped.
+	MaxOffset int64
+	// RequireSort rejects requests without a sort, unless DefaultSort is set.
+	RequireSort bool
+	// DefaultSort is the sort used when the client does not send one, with API field names.
+	DefaultSort []SortField
+	// Clamp brings out of range limits and offsets back into range instead of rejecting them.
+	Clamp bool
+}
+
+// GetDefaultLimit returns the limit used when the client does not send one, defaulting to DefaultPaginationLimit.
+func (o *PaginationOptions) GetDefaultLimit() int64 {
+	if o.DefaultLimit <= 0 {
+		return DefaultPaginationLimit
+	}
+	return o.DefaultLimit
+}
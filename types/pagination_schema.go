@@ -0,0 +1,65 @@
+package types
+
+import "github.com/educolog9/packages/enums"
+
+// FieldSchema describes how clients of an endpoint may use a field in Pagination.
+type FieldSchema struct {
+	// Path is the document path the field maps to. When empty, the field name is used as is.
+	Path string
+	// Filterable allows the field in Pagination.Filters and Pagination.Facets.
+	Filterable bool
+	// Sortable allows the field in Pagination.Sort and Pagination.Sorts.
+	Sortable bool
+	// Projectable allows the field in Pagination.Fields and Pagination.ExcludeFields.
+	Projectable bool
+	// Operators lists the operators allowed on the field. When empty, every operator is allowed.
+	Operators []enums.Operator
+	// Type is the value type of the field. It is set as the type hint of every filter on the field.
+	Type enums.CustomTypes
+	// Match configures like and notLike filters on the field. It is set on every filter on the field.
+	Match *MatchOptions
+	// Elements is the schema of the fields of the elements of an array field, against which the filters
+	// of its elemMatch filters are checked. Filters on the elements themselves, for arrays of scalars, are
+	// checked against the field declared with an empty name. elemMatch is not allowed on fields without it.
+	Elements *PaginationSchema
+}
+
+// GetPath returns the document path of the field.
+func (f *FieldSchema) GetPath(name string) string {
+	if f.Path == "" {
+		return name
+	}
+	return f.Path
+}
+
+// AllowsOperator checks if the operator can be used on the field.
+func (f *FieldSchema) AllowsOperator(operator enums.Operator) bool {
+	if len(f.Operators) == 0 {
+		return true
+	}
+	for _, allowed := range f.Operators {
+		if allowed == operator {
+			return true
+		}
+	}
+	return false
+}
+
+// PaginationSchema is the allowlist of fields an endpoint accepts in Pagination, keyed by the API field name.
+// Fields that are not declared cannot be used to filter or sort.
+type PaginationSchema struct {
+	Fields map[string]FieldSchema
+}
+
+// NewPaginationSchema creates a new PaginationSchema with the given fields.
+func NewPaginationSchema(fields map[string]FieldSchema) *PaginationSchema {
+	return &PaginationSchema{
+		Fields: fields,
+	}
+}
+
+// GetField returns the schema of the field with the given API name.
+func (s *PaginationSchema) GetField(name string) (FieldSchema, bool) {
+	field, ok := s.Fields[name]
+	return field, ok
+}
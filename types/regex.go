@@ -0,0 +1,191 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidateRegex checks a client supplied regular expression before it reaches MongoDB.
+// It rejects invalid expressions, expressions with more repetition operators than allowed,
+// repeated groups holding a repetition or an alternation such as (a+)+ or (a|ab)*, which cause
+// catastrophic backtracking, and expressions that match the empty string such as ".*", which match
+// every value. Repetitions are checked on the pattern as written, since MongoDB runs it as is.
+func (m *MatchOptions) ValidateRegex(pattern string) error {
+	if _, err := syntax.Parse(pattern, syntax.Perl); err != nil {
+		return fmt.Errorf("invalid regular expression: %w", err)
+	}
+
+	scan := scanRegexRepetitions(pattern)
+	if scan.nestedRepeat {
+		return fmt.Errorf("nested repetition operators are not allowed")
+	}
+	if scan.repeatedAlternation {
+		return fmt.Errorf("repeated alternations are not allowed")
+	}
+	if scan.quantifiers > m.GetMaxQuantifiers() {
+		return fmt.Errorf("at most %d repetition operators are allowed", m.GetMaxQuantifiers())
+	}
+
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid regular expression: %w", err)
+	}
+	if compiled.MatchString("") {
+		return fmt.Errorf("the regular expression matches every value")
+	}
+
+	return nil
+}
+
+// regexScan is the result of scanRegexRepetitions.
+type regexScan struct {
+	quantifiers         int
+	nestedRepeat        bool
+	repeatedAlternation bool
+}
+
+// regexGroup tracks what a group of a pattern holds, including its inner groups.
+type regexGroup struct {
+	hasRepeat      bool
+	hasAlternation bool
+}
+
+// scanRegexRepetitions walks a valid pattern as written, skipping escapes and character classes, counting
+// its repetition operators and reporting groups repeated more than once that hold a repetition or an alternation.
+func scanRegexRepetitions(pattern string) regexScan {
+	var scan regexScan
+	groups := []*regexGroup{{}}
+	var last *regexGroup
+
+	for i := 0; i < len(pattern); {
+		switch c := pattern[i]; c {
+		case '\\':
+			if strings.HasPrefix(pattern[i:], `\Q`) {
+				end := strings.Index(pattern[i+2:], `\E`)
+				if end < 0 {
+					i = len(pattern)
+				} else {
+					i += 2 + end + 2
+				}
+			} else {
+				i += 2
+			}
+			last = nil
+		case '[':
+			i = skipRegexClass(pattern, i)
+			last = nil
+		case '(':
+			groups = append(groups, &regexGroup{})
+			i++
+			if i < len(pattern) && pattern[i] == '?' {
+				i++
+			}
+			last = nil
+		case ')':
+			last = groups[len(groups)-1]
+			groups = groups[:len(groups)-1]
+			parent := groups[len(groups)-1]
+			parent.hasRepeat = parent.hasRepeat || last.hasRepeat
+			parent.hasAlternation = parent.hasAlternation || last.hasAlternation
+			i++
+		case '|':
+			groups[len(groups)-1].hasAlternation = true
+			last = nil
+			i++
+		case '*', '+', '?', '{':
+			size, repeats := parseRegexQuantifier(pattern[i:])
+			if size == 0 {
+				last = nil
+				i++
+				continue
+			}
+
+			scan.quantifiers++
+			groups[len(groups)-1].hasRepeat = true
+			if last != nil && repeats {
+				scan.nestedRepeat = scan.nestedRepeat || last.hasRepeat
+				scan.repeatedAlternation = scan.repeatedAlternation || last.hasAlternation
+			}
+
+			i += size
+			if i < len(pattern) && pattern[i] == '?' {
+				i++
+			}
+			last = nil
+		default:
+			_, size := utf8.DecodeRuneInString(pattern[i:])
+			i += size
+			last = nil
+		}
+	}
+
+	return scan
+}
+
+// parseRegexQuantifier reads the quantifier at the start of a pattern, returning its length, or zero when a
+// brace does not start a quantifier, and whether it repeats its operand more than once.
+func parseRegexQuantifier(pattern string) (int, bool) {
+	switch pattern[0] {
+	case '*', '+':
+		return 1, true
+	case '?':
+		return 1, false
+	}
+
+	end := strings.IndexByte(pattern, '}')
+	if end < 0 {
+		return 0, false
+	}
+
+	bounds := strings.SplitN(pattern[1:end], ",", 2)
+	minimum, err := strconv.Atoi(bounds[0])
+	if err != nil {
+		return 0, false
+	}
+	if len(bounds) == 1 {
+		return end + 1, minimum > 1
+	}
+	if bounds[1] == "" {
+		return end + 1, true
+	}
+	maximum, err := strconv.Atoi(bounds[1])
+	if err != nil {
+		return 0, false
+	}
+	return end + 1, maximum > 1
+}
+
+// skipRegexClass returns the position after the character class starting at the given position.
+// A "]" right after the opening bracket, or after "[^", is a literal, as are escaped characters.
+func skipRegexClass(pattern string, start int) int {
+	i := start + 1
+	if i < len(pattern) && pattern[i] == '^' {
+		i++
+	}
+	if i < len(pattern) && pattern[i] == ']' {
+		i++
+	}
+
+	for i < len(pattern) {
+		switch {
+		case pattern[i] == '\\':
+			i += 2
+		case strings.HasPrefix(pattern[i:], "[:"):
+			if end := strings.Index(pattern[i+2:], ":]"); end >= 0 {
+				i += 2 + end + 2
+			} else {
+				i++
+			}
+		case pattern[i] == ']':
+			return i + 1
+		default:
+			i++
+		}
+	}
+
+	return i
+}
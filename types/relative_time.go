@@ -0,0 +1,113 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// relativeTimePattern matches relative date expressions: an anchor followed by any number of offsets,
+// e.g. "now-7d", "startOfMonth", "endOfDay+1d" or "startOfWeek-2w".
+var relativeTimePattern = regexp.MustCompile(`^(now|today|(?:startOf|endOf)(?:Day|Week|Month|Year))((?:[+-]\d+[smhdwMy])*)$`)
+
+// relativeTimeOffsetPattern matches a single offset of a relative date expression.
+var relativeTimeOffsetPattern = regexp.MustCompile(`([+-])(\d+)([smhdwMy])`)
+
+// isRelativeTime checks if a value is a relative date expression.
+func isRelativeTime(value string) bool {
+	return relativeTimePattern.MatchString(strings.TrimSpace(value))
+}
+
+// parseRelativeTime resolves a relative date expression at the given time, in its time zone.
+// The anchor is "now", "today" (the start of the day) or the start or end of the day, the ISO week
+// (starting on Monday), the month or the year. The offsets are applied in order: s, m and h are seconds,
+// minutes and hours, while d, w, M and y are calendar days, weeks, months and years.
+// End anchors are resolved after the offsets, so "endOfMonth-1M" is the last millisecond of the previous month.
+func parseRelativeTime(value string, now time.Time) (time.Time, error) {
+	match := relativeTimePattern.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return time.Time{}, fmt.Errorf("%q is not a valid relative date", value)
+	}
+
+	anchor, offsets := match[1], match[2]
+
+	t := now
+	unit := ""
+	switch {
+	case anchor == "today":
+		unit = "Day"
+		t = startOfPeriod(now, unit)
+	case strings.HasPrefix(anchor, "startOf"):
+		unit = strings.TrimPrefix(anchor, "startOf")
+		t = startOfPeriod(now, unit)
+	case strings.HasPrefix(anchor, "endOf"):
+		unit = strings.TrimPrefix(anchor, "endOf")
+		t = startOfPeriod(now, unit)
+	}
+
+	for _, offset := range relativeTimeOffsetPattern.FindAllStringSubmatch(offsets, -1) {
+		amount, err := strconv.Atoi(offset[2])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%q is not a valid relative date", value)
+		}
+		if offset[1] == "-" {
+			amount = -amount
+		}
+
+		switch offset[3] {
+		case "s":
+			t = t.Add(time.Duration(amount) * time.Second)
+		case "m":
+			t = t.Add(time.Duration(amount) * time.Minute)
+		case "h":
+			t = t.Add(time.Duration(amount) * time.Hour)
+		case "d":
+			t = t.AddDate(0, 0, amount)
+		case "w":
+			t = t.AddDate(0, 0, 7*amount)
+		case "M":
+			t = t.AddDate(0, amount, 0)
+		case "y":
+			t = t.AddDate(amount, 0, 0)
+		}
+	}
+
+	if strings.HasPrefix(anchor, "endOf") {
+		t = nextPeriod(t, unit).Add(-time.Millisecond)
+	}
+
+	return t, nil
+}
+
+// startOfPeriod returns the start of the day, ISO week, month or year of a time, in its time zone.
+func startOfPeriod(t time.Time, unit string) time.Time {
+	year, month, day := t.Date()
+
+	switch unit {
+	case "Week":
+		weekday := (int(t.Weekday()) + 6) % 7
+		return time.Date(year, month, day-weekday, 0, 0, 0, 0, t.Location())
+	case "Month":
+		return time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+	case "Year":
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+	}
+}
+
+// nextPeriod returns the start of the period following the one starting at t.
+func nextPeriod(t time.Time, unit string) time.Time {
+	switch unit {
+	case "Week":
+		return t.AddDate(0, 0, 7)
+	case "Month":
+		return t.AddDate(0, 1, 0)
+	case "Year":
+		return t.AddDate(1, 0, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
@@ -0,0 +1,17 @@
+package types
+
+// SQLOptions configures the SQL clauses generated by the SQL converter of the databases package.
+type SQLOptions struct {
+	// KeyColumn is the unique column used as the sort tiebreaker and in keyset cursors. Defaults to "id".
+	KeyColumn string
+	// SearchColumns are the columns matched by Pagination.Search. Searching requires at least one.
+	SearchColumns []string
+}
+
+// GetKeyColumn returns the tiebreaker column, defaulting to "id".
+func (o *SQLOptions) GetKeyColumn() string {
+	if o.KeyColumn == "" {
+		return "id"
+	}
+	return o.KeyColumn
+}
@@ -0,0 +1,90 @@
+package types
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// DefaultOrganizationField is the document field holding the organization when a Tenant does not set one.
+const DefaultOrganizationField = "organization"
+
+// Tenant scopes queries to the organization of the authenticated user.
+// The databases converters and Repository add a condition on Field to every query of a scoped
+// PaginationConfig or context, and reject client filters on that field.
+type Tenant struct {
+	// OrganizationID is the organization every query is restricted to, stored as a string as it is in the claims.
+	OrganizationID string
+	// Field is the document field holding the organization. Defaults to DefaultOrganizationField.
+	Field string
+	// UserID is the user the tenant was created for, used when auditing bypasses.
+	UserID string
+	// bypass disables the scope. It can only be set through NewAdminBypassTenant.
+	bypass bool
+}
+
+// TenancyBypassAuditor records every admin bypass of the tenancy scope. It logs the bypass by default
+// and can be replaced to send the events to an audit store.
+var TenancyBypassAuditor = func(ctx context.Context, tenant *Tenant, reason string) {
+	log.Printf("tenancy bypass by user %s of organization %s: %s", tenant.UserID, tenant.OrganizationID, reason)
+}
+
+// NewTenant creates a new Tenant for the organization of the given claims.
+// It returns an error when the claims have no organization.
+func NewTenant(claims *UserClaims, field string) (*Tenant, error) {
+	if claims == nil || claims.OrganizationID == "" {
+		return nil, fmt.Errorf("the user does not belong to an organization")
+	}
+
+	return &Tenant{
+		OrganizationID: claims.OrganizationID,
+		Field:          field,
+		UserID:         claims.ID,
+	}, nil
+}
+
+// NewAdminBypassTenant creates a Tenant whose queries are not scoped to any organization.
+// Only admins can bypass the scope, a reason is required, and the bypass is recorded with
+// TenancyBypassAuditor before the tenant is returned.
+func NewAdminBypassTenant(ctx context.Context, claims *UserClaims, reason string) (*Tenant, error) {
+	if claims == nil || !claims.IsAdmin() {
+		return nil, fmt.Errorf("only admins can bypass the organization scope")
+	}
+	if reason == "" {
+		return nil, fmt.Errorf("a reason is required to bypass the organization scope")
+	}
+
+	tenant := &Tenant{
+		OrganizationID: claims.OrganizationID,
+		UserID:         claims.ID,
+		bypass:         true,
+	}
+
+	TenancyBypassAuditor(ctx, tenant, reason)
+
+	return tenant, nil
+}
+
+// GetField returns the document field holding the organization.
+func (t *Tenant) GetField() string {
+	if t.Field == "" {
+		return DefaultOrganizationField
+	}
+	return t.Field
+}
+
+// IsBypass reports whether the tenant was created with NewAdminBypassTenant.
+func (t *Tenant) IsBypass() bool {
+	return t.bypass
+}
+
+// WithTenant returns a copy of the context holding the tenant.
+func WithTenant(ctx context.Context, tenant *Tenant) context.Context {
+	return context.WithValue(ctx, TenantKey, tenant)
+}
+
+// TenantFromContext returns the tenant stored in the context, or nil when there is none.
+func TenantFromContext(ctx context.Context) *Tenant {
+	tenant, _ := ctx.Value(TenantKey).(*Tenant)
+	return tenant
+}
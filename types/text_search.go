@@ -0,0 +1,16 @@
+package types
+
+// TextSearchOptions configures the $text search and the collation generated by the Mongo converters
+// when PaginationConfig.Language is set.
+type TextSearchOptions struct {
+	// CaseSensitive makes the text search and the collation distinguish letter case.
+	CaseSensitive bool
+	// DiacriticSensitive makes the text search and the collation distinguish accented letters
+	// from their base letter (e.g. "José" and "Jose"). "ñ" is always distinct from "n" in Spanish.
+	DiacriticSensitive bool
+	// Collation makes the Mongo queries compare and sort strings with the collation of the language,
+	// following the same case and diacritic rules as the $text search. Queries only use indexes created
+	// with the same collation, so enable it only for collections whose default collation, or whose
+	// indexes, use it; see databases.IndexDeclaration.
+	Collation bool
+}
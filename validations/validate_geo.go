@@ -1,6 +1,7 @@
 package validations
 
 import (
+	"github.com/educolog9/packages/types"
 	"github.com/go-playground/validator/v10"
 )
 
@@ -8,12 +9,12 @@ func validateLatitude(fl validator.FieldLevel) bool {
 	lat := fl.Field().Float()
 
 	// Check if the latitude is a valid (between -90 and 90)
-	return lat >= -90 && lat <= 90
+	return types.IsValidLatitude(lat)
 }
 
 func validateLongitude(fl validator.FieldLevel) bool {
 	lon := fl.Field().Float()
 
 	// Check if the longitude is a valid (between -180 and 180)
-	return lon >= -180 && lon <= 180
+	return types.IsValidLongitude(lon)
 }
@@ -0,0 +1,261 @@
+package validations
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/educolog9/packages/enums"
+	"github.com/educolog9/packages/types"
+	ut "github.com/go-playground/universal-translator"
+)
+
+// Pagination violation tags, used as translation keys.
+const (
+	PaginationFilterField    = "paginationFilterField"
+	PaginationFilterOperator = "paginationFilterOperator"
+	PaginationFilterType     = "paginationFilterType"
+	PaginationFilterValue    = "paginationFilterValue"
+	PaginationSortField      = "paginationSortField"
+	PaginationProjectField   = "paginationProjectField"
+	PaginationProjectMixed   = "paginationProjectMixed"
+	PaginationProjectOverlap = "paginationProjectOverlap"
+	PaginationFacetField     = "paginationFacetField"
+	PaginationFacetValue     = "paginationFacetValue"
+	PaginationNegative       = "paginationNegative"
+	PaginationMax            = "paginationMax"
+	PaginationSortRequired   = "paginationSortRequired"
+)
+
+// PaginationViolation describes a part of a Pagination that is not allowed by a PaginationSchema.
+type PaginationViolation struct {
+	Tag   string
+	Field string
+	Param string
+}
+
+// NewPaginationViolation creates a new PaginationViolation with the given tag, field and param.
+func NewPaginationViolation(tag string, field string, param string) *PaginationViolation {
+	return &PaginationViolation{
+		Tag:   tag,
+		Field: field,
+		Param: param,
+	}
+}
+
+func (v *PaginationViolation) Error() string {
+	return fmt.Sprintf("%s: field %s %s", v.Tag, v.Field, v.Param)
+}
+
+// Translate returns the violation message in the language of the given translator.
+func (v *PaginationViolation) Translate(trans ut.Translator) string {
+	params := []string{v.Field}
+	if v.Param != "" {
+		params = append(params, v.Param)
+	}
+
+	t, err := trans.T(v.Tag, params...)
+	if err != nil {
+		return v.Error()
+	}
+	return t
+}
+
+// ValidatePagination checks the filters, sorts and projected fields of a pagination against the schema of an endpoint.
+// Every field must be declared in the schema, filterable to be used in a filter, sortable to be used
+// in a sort, projectable to be requested in fields or excludeFields, filterable to be counted in a facet, and filters must use an operator allowed on the field, a type hint matching the field type
+// and a value the converters can translate. The filters of an elemMatch filter are checked the same way against
+// the Elements schema of its field. Projected fields cannot overlap, such as "address" and "address.city".
+// The pagination is not modified; the API field names are mapped to document paths by the middlewares.
+// It returns the list of violations found, which is empty when the pagination is valid.
+func ValidatePagination(pagination *types.Pagination, schema *types.PaginationSchema) []*PaginationViolation {
+	_, violations := validatePaginationFilters(pagination.Filters, schema)
+
+	if pagination.Sort != "" {
+		if violation := validateSortField(pagination.Sort, schema); violation != nil {
+			violations = append(violations, violation)
+		}
+	}
+
+	for _, sort := range pagination.Sorts {
+		if violation := validateSortField(sort.Field, schema); violation != nil {
+			violations = append(violations, violation)
+		}
+	}
+
+	if len(pagination.Fields) > 0 && len(pagination.ExcludeFields) > 0 {
+		violations = append(violations, NewPaginationViolation(PaginationProjectMixed, "excludeFields", "fields"))
+	}
+
+	violations = append(violations, validateProjectFields(pagination.Fields, schema)...)
+	violations = append(violations, validateProjectFields(pagination.ExcludeFields, schema)...)
+	violations = append(violations, validatePaginationFacets(pagination.Facets, schema)...)
+
+	return violations
+}
+
+// validatePaginationFilters checks the filters against the schema. It returns copies of the filters with the
+// type and match options of their fields, as the converters see them, which are used to check the values of
+// elemMatch filters against the schema of the elements of their field.
+func validatePaginationFilters(filters []types.Filter, schema *types.PaginationSchema) ([]types.Filter, []*PaginationViolation) {
+	var violations []*PaginationViolation
+	typed := types.CloneFilters(filters)
+
+	for i := range typed {
+		f := &typed[i]
+
+		if f.IsGroup() {
+			children, groupViolations := validatePaginationFilters(f.Filters, schema)
+			violations = append(violations, groupViolations...)
+			f.Filters = children
+			continue
+		}
+
+		field, ok := schema.GetField(f.Field)
+		if !ok || !field.Filterable || strings.HasPrefix(f.Field, "$") {
+			violations = append(violations, NewPaginationViolation(PaginationFilterField, f.Field, ""))
+			continue
+		}
+
+		if !field.AllowsOperator(f.Operator) {
+			violations = append(violations, NewPaginationViolation(PaginationFilterOperator, f.Field, string(f.Operator)))
+			continue
+		}
+
+		if f.Operator == enums.ElemMatch {
+			children, elementViolations := validateElemMatchFilter(f, field)
+			if len(elementViolations) > 0 {
+				violations = append(violations, elementViolations...)
+				continue
+			}
+			f.Value = children
+		}
+
+		if field.Type != "" {
+			if f.Type != "" && f.Type != field.Type {
+				violations = append(violations, NewPaginationViolation(PaginationFilterType, f.Field, string(field.Type)))
+				continue
+			}
+			f.Type = field.Type
+		}
+		f.Match = field.Match
+
+		if err := f.Validate(); err != nil {
+			violations = append(violations, NewPaginationViolation(PaginationFilterValue, f.Field, err.Error()))
+		}
+	}
+
+	return typed, violations
+}
+
+// validateElemMatchFilter checks the filters of an elemMatch filter against the schema of the elements of its
+// field, returning them with the types of their fields. The violations found in them are named after the field
+// of the filter, e.g. "items.price".
+func validateElemMatchFilter(f *types.Filter, field types.FieldSchema) ([]types.Filter, []*PaginationViolation) {
+	if field.Elements == nil {
+		return nil, []*PaginationViolation{NewPaginationViolation(PaginationFilterOperator, f.Field, string(f.Operator))}
+	}
+
+	filters, err := f.ElemMatchFilters()
+	if err != nil {
+		return nil, []*PaginationViolation{NewPaginationViolation(PaginationFilterValue, f.Field, err.Error())}
+	}
+
+	typed, violations := validatePaginationFilters(filters, field.Elements)
+	for _, violation := range violations {
+		if violation.Field == "" {
+			violation.Field = f.Field
+		} else {
+			violation.Field = f.Field + "." + violation.Field
+		}
+	}
+
+	return typed, violations
+}
+
+func validateSortField(name string, schema *types.PaginationSchema) *PaginationViolation {
+	field, ok := schema.GetField(name)
+	if !ok || !field.Sortable || strings.HasPrefix(name, "$") {
+		return NewPaginationViolation(PaginationSortField, name, "")
+	}
+	return nil
+}
+
+// validateProjectFields returns a violation for every projected field that is not projectable and for every
+// field whose document path is a parent or a child of the path of another field, such as "address" and
+// "address.city", which MongoDB rejects.
+func validateProjectFields(fields []string, schema *types.PaginationSchema) []*PaginationViolation {
+	var violations []*PaginationViolation
+	paths := make([]string, len(fields))
+
+	for i, name := range fields {
+		field, ok := schema.GetField(name)
+		if !ok || !field.Projectable || strings.HasPrefix(name, "$") {
+			violations = append(violations, NewPaginationViolation(PaginationProjectField, name, ""))
+			continue
+		}
+		paths[i] = field.GetPath(name)
+
+		for j := 0; j < i; j++ {
+			if paths[j] != "" && (strings.HasPrefix(paths[i], paths[j]+".") || strings.HasPrefix(paths[j], paths[i]+".")) {
+				violations = append(violations, NewPaginationViolation(PaginationProjectOverlap, name, fields[j]))
+				break
+			}
+		}
+	}
+
+	return violations
+}
+
+// validatePaginationFacets returns a violation for every facet on a field that is not filterable and for every
+// facet the converters cannot translate.
+func validatePaginationFacets(facets []types.FacetRequest, schema *types.PaginationSchema) []*PaginationViolation {
+	var violations []*PaginationViolation
+
+	for _, f := range facets {
+		field, ok := schema.GetField(f.Field)
+		if !ok || !field.Filterable || strings.HasPrefix(f.Field, "$") {
+			violations = append(violations, NewPaginationViolation(PaginationFacetField, f.Field, ""))
+			continue
+		}
+
+		if err := f.Validate(); err != nil {
+			violations = append(violations, NewPaginationViolation(PaginationFacetValue, f.Name, err.Error()))
+		}
+	}
+
+	return violations
+}
+
+// registerENPaginationTranslations registers the English messages of the pagination violations.
+func registerENPaginationTranslations(trans ut.Translator) {
+	_ = trans.Add(PaginationFilterField, "The field {0} cannot be used to filter", true)
+	_ = trans.Add(PaginationFilterOperator, "The field {0} does not allow the operator {1}", true)
+	_ = trans.Add(PaginationFilterType, "The field {0} must be of type {1}", true)
+	_ = trans.Add(PaginationFilterValue, "The value of the field {0} is not valid: {1}", true)
+	_ = trans.Add(PaginationSortField, "The field {0} cannot be used to sort", true)
+	_ = trans.Add(PaginationProjectField, "The field {0} cannot be requested", true)
+	_ = trans.Add(PaginationProjectMixed, "The field {0} cannot be combined with {1}", true)
+	_ = trans.Add(PaginationProjectOverlap, "The field {0} overlaps with the field {1}", true)
+	_ = trans.Add(PaginationFacetField, "The field {0} cannot be used in a facet", true)
+	_ = trans.Add(PaginationFacetValue, "The facet {0} is not valid: {1}", true)
+	_ = trans.Add(PaginationNegative, "The field {0} cannot be negative", true)
+	_ = trans.Add(PaginationMax, "The field {0} cannot be greater than {1}", true)
+	_ = trans.Add(PaginationSortRequired, "The field {0} is required", true)
+}
+
+// registerESPaginationTranslations registers the Spanish messages of the pagination violations.
+func registerESPaginationTranslations(trans ut.Translator) {
+	_ = trans.Add(PaginationFilterField, "El campo {0} no se puede usar para filtrar", true)
+	_ = trans.Add(PaginationFilterOperator, "El campo {0} no permite el operador {1}", true)
+	_ = trans.Add(PaginationFilterType, "El campo {0} debe ser de tipo {1}", true)
+	_ = trans.Add(PaginationFilterValue, "El valor del campo {0} no es válido: {1}", true)
+	_ = trans.Add(PaginationSortField, "El campo {0} no se puede usar para ordenar", true)
+	_ = trans.Add(PaginationProjectField, "El campo {0} no se puede solicitar", true)
+	_ = trans.Add(PaginationProjectMixed, "El campo {0} no se puede combinar con {1}", true)
+	_ = trans.Add(PaginationProjectOverlap, "El campo {0} se superpone con el campo {1}", true)
+	_ = trans.Add(PaginationFacetField, "El campo {0} no se puede usar en una faceta", true)
+	_ = trans.Add(PaginationFacetValue, "La faceta {0} no es válida: {1}", true)
+	_ = trans.Add(PaginationNegative, "El campo {0} no puede ser negativo", true)
+	_ = trans.Add(PaginationMax, "El campo {0} no puede ser mayor que {1}", true)
+	_ = trans.Add(PaginationSortRequired, "El campo {0} es requerido", true)
+}
@@ -0,0 +1,53 @@
+package validations
+
+import (
+	"strconv"
+
+	"github.com/educolog9/packages/types"
+)
+
+// ValidatePaginationOptions checks the limit, offset and sort of a pagination against the options of an endpoint.
+// A missing limit gets the default limit and a missing sort gets the default sort. Negative limits and offsets,
+// and limits and offsets over their maximum, are violations, unless the options clamp them back into range.
+// A missing sort is a violation when the options require one and have no default sort.
+// The schema of the options is not checked here; see ValidatePagination.
+// It returns the list of violations found, which is empty when the pagination is valid.
+func ValidatePaginationOptions(pagination *types.Pagination, options *types.PaginationOptions) []*PaginationViolation {
+	var violations []*PaginationViolation
+
+	if pagination.Limit == 0 {
+		pagination.Limit = options.GetDefaultLimit()
+	}
+
+	switch {
+	case pagination.Limit < 0 && options.Clamp:
+		pagination.Limit = options.GetDefaultLimit()
+	case pagination.Limit < 0:
+		violations = append(violations, NewPaginationViolation(PaginationNegative, "limit", ""))
+	case options.MaxLimit > 0 && pagination.Limit > options.MaxLimit && options.Clamp:
+		pagination.Limit = options.MaxLimit
+	case options.MaxLimit > 0 && pagination.Limit > options.MaxLimit:
+		violations = append(violations, NewPaginationViolation(PaginationMax, "limit", strconv.FormatInt(options.MaxLimit, 10)))
+	}
+
+	switch {
+	case pagination.Offset < 0 && options.Clamp:
+		pagination.Offset = 0
+	case pagination.Offset < 0:
+		violations = append(violations, NewPaginationViolation(PaginationNegative, "offset", ""))
+	case options.MaxOffset > 0 && pagination.Offset > options.MaxOffset && options.Clamp:
+		pagination.Offset = options.MaxOffset
+	case options.MaxOffset > 0 && pagination.Offset > options.MaxOffset:
+		violations = append(violations, NewPaginationViolation(PaginationMax, "offset", strconv.FormatInt(options.MaxOffset, 10)))
+	}
+
+	if len(pagination.GetSorts()) == 0 {
+		if len(options.DefaultSort) > 0 {
+			pagination.Sorts = append([]types.SortField(nil), options.DefaultSort...)
+		} else if options.RequireSort {
+			violations = append(violations, NewPaginationViolation(PaginationSortRequired, "sort", ""))
+		}
+	}
+
+	return violations
+}
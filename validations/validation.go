@@ -37,10 +37,12 @@ func init() {
 	// Set the translator for English
 	transEn, _ := Uni.GetTranslator("en")
 	registerENTranslations(transEn)
+	registerENPaginationTranslations(transEn)
 
 	// Set the translator for Spanish
 	transEs, _ := Uni.GetTranslator("es")
 	registerESTranslations(transEs)
+	registerESPaginationTranslations(transEs)
 }
 
 // registerENTranslations registers custom translations for validation tags in English.